@@ -0,0 +1,350 @@
+// Command tggen generates the Bot API types and methods of package tg
+// from the machine-readable specification in tg/botapi.json.
+//
+// Every type and method of the specification is a list of fields. A field has
+// the JSON name and the Go type and may be marked as:
+//
+//	optional - omitted from the request when empty
+//	file     - can be uploaded, adds a <Name>AsFile *InputFile field
+//	embed    - the Go type is embedded and validated before sending
+//	local    - not sent to Telegram, used by validation only
+//
+// A field of type InputFile can be uploaded only. Fields of InputMedia types
+// contribute their files to multipart requests.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"strings"
+	"unicode"
+)
+
+type Constant struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Field struct {
+	Name     string `json:"name"`
+	GoName   string `json:"go_name"`
+	Type     string `json:"type"`
+	Optional bool   `json:"optional"`
+	Comment  string `json:"comment"`
+	File     bool   `json:"file"`
+	Embed    bool   `json:"embed"`
+	Local    bool   `json:"local"`
+}
+
+type Type struct {
+	Name      string        `json:"name"`
+	Constants [][]*Constant `json:"constants"`
+	Fields    []*Field      `json:"fields"`
+}
+
+type Method struct {
+	Name           string        `json:"name"`
+	Constants      [][]*Constant `json:"constants"`
+	Fields         []*Field      `json:"fields"`
+	Result         string        `json:"result"`
+	OptionalResult bool          `json:"optional_result"`
+	LongPolling    bool          `json:"long_polling"`
+	Validate       bool          `json:"validate"`
+}
+
+type Spec struct {
+	Version string    `json:"version"`
+	DocURL  string    `json:"doc_url"`
+	Types   []*Type   `json:"types"`
+	Methods []*Method `json:"methods"`
+}
+
+var initialisms = map[string]string{
+	"id":    "ID",
+	"url":   "URL",
+	"mpeg4": "MPEG4",
+}
+
+func (f *Field) goName() string {
+	if f.GoName != "" {
+		return f.GoName
+	}
+	var name strings.Builder
+	for _, part := range strings.Split(f.Name, "_") {
+		if initialism, ok := initialisms[part]; ok {
+			name.WriteString(initialism)
+		} else if part != "" {
+			name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return name.String()
+}
+
+func (f *Field) isUpload() bool {
+	return f.File || f.Type == "InputFile"
+}
+
+func (f *Field) isMedia() bool {
+	return strings.TrimLeft(f.Type, "[]*") == "InputMedia"
+}
+
+func exportedName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// resultType returns the Go type of a method result,
+// struct results are returned by pointer.
+func resultType(result string) string {
+	if unicode.IsUpper(rune(result[0])) {
+		return "*" + result
+	}
+	return result
+}
+
+type writer struct {
+	bytes.Buffer
+}
+
+func (w *writer) line(format string, args ...interface{}) {
+	fmt.Fprintf(w, format+"\n", args...)
+}
+
+func (w *writer) constants(groups [][]*Constant) {
+	for _, group := range groups {
+		w.line("const (")
+		for _, constant := range group {
+			w.line("\t%s = %q", constant.Name, constant.Value)
+		}
+		w.line(")")
+		w.line("")
+	}
+}
+
+func (w *writer) structure(name string, fields []*Field) {
+	w.line("type %s struct {", name)
+	var uploads []*Field
+	for _, field := range fields {
+		switch {
+		case field.Embed:
+			w.line("\t%s", field.Type)
+		case field.Local:
+			w.fieldLine(field.goName(), field.Type, "-", field.Comment)
+		case field.Type == "InputFile":
+		default:
+			tag := field.Name
+			if field.Optional {
+				tag += ",omitempty"
+			}
+			w.fieldLine(field.goName(), field.Type, tag, field.Comment)
+		}
+		if field.isUpload() {
+			uploads = append(uploads, field)
+		}
+	}
+	for _, field := range uploads {
+		w.fieldLine(field.goName()+"AsFile", "*InputFile", "-", "")
+	}
+	w.line("}")
+	w.line("")
+}
+
+func (w *writer) fieldLine(name string, goType string, tag string, comment string) {
+	if comment != "" {
+		w.line("\t%s %s `json:\"%s\"` // %s", name, goType, tag, comment)
+	} else {
+		w.line("\t%s %s `json:\"%s\"`", name, goType, tag)
+	}
+}
+
+func (w *writer) getMedia(t *Type) {
+	var files []string
+	for _, field := range t.Fields {
+		if field.isUpload() {
+			files = append(files, "m."+field.goName()+"AsFile")
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+	w.line("func (m *%s) getMedia() []*InputFile {", t.Name)
+	w.line("\treturn []*InputFile{%s}", strings.Join(files, ", "))
+	w.line("}")
+	w.line("")
+}
+
+func (w *writer) getRequestArgs(name string, m *Method) {
+	w.line("func (p *%s) GetRequestArgs() (*RequestArgs, error) {", name)
+	w.line("\treturn p.getRequestArgs(defaultCodec)")
+	w.line("}")
+	w.line("")
+	w.line("func (p *%s) getRequestArgs(codec Codec) (*RequestArgs, error) {", name)
+	for _, field := range m.Fields {
+		if field.Embed {
+			w.line("\tif err := p.%s.validate(); err != nil {", strings.TrimLeft(field.Type, "*"))
+			w.line("\t\treturn nil, err")
+			w.line("\t}")
+		}
+	}
+	if m.Validate {
+		w.line("\tif err := p.validate(); err != nil {")
+		w.line("\t\treturn nil, err")
+		w.line("\t}")
+	}
+	var uploads []string
+	for _, field := range m.Fields {
+		if field.isUpload() {
+			uploads = append(uploads, "p."+field.goName()+"AsFile")
+		}
+		if !field.isMedia() {
+			continue
+		}
+		w.line("\tvar files []*InputFile")
+		if strings.HasPrefix(field.Type, "[]") {
+			w.line("\tfor _, media := range p.%s {", field.goName())
+		} else {
+			w.line("\tif p.%s != nil && *p.%s != nil {", field.goName(), field.goName())
+			w.line("\t\tmedia := *p.%s", field.goName())
+		}
+		w.line("\t\tfor _, file := range media.getMedia() {")
+		w.line("\t\t\tif file.isAllSet() {")
+		w.line("\t\t\t\tfiles = append(files, file)")
+		w.line("\t\t\t}")
+		w.line("\t\t}")
+		w.line("\t}")
+		w.line("\tif len(files) > 0 {")
+		w.line("\t\targs := marshallToMap(codec, p)")
+		w.line("\t\treturn buildMultipartRequestArgs(args, files)")
+		w.line("\t}")
+	}
+	switch len(uploads) {
+	case 0:
+	case 1:
+		w.line("\tif %s.isAllSet() {", uploads[0])
+		w.line("\t\targs := marshallToMap(codec, p)")
+		w.line("\t\treturn buildMultipartRequestArgs(args, []*InputFile{%s})", uploads[0])
+		w.line("\t}")
+	default:
+		conditions := make([]string, len(uploads))
+		for i, upload := range uploads {
+			conditions[i] = upload + ".isAllSet()"
+		}
+		w.line("\tif %s {", strings.Join(conditions, " || "))
+		w.line("\t\targs := marshallToMap(codec, p)")
+		w.line("\t\tvar files []*InputFile")
+		for _, upload := range uploads {
+			w.line("\t\tif %s.isAllSet() {", upload)
+			w.line("\t\t\tfiles = append(files, %s)", upload)
+			w.line("\t\t}")
+		}
+		w.line("\t\treturn buildMultipartRequestArgs(args, files)")
+		w.line("\t}")
+	}
+	w.line("\treturn buildJSONRequestArgs(codec, p)")
+	w.line("}")
+	w.line("")
+}
+
+func (w *writer) method(spec *Spec, m *Method) {
+	name := exportedName(m.Name)
+	argsName := name + "Args"
+	w.constants(m.Constants)
+	w.structure(argsName, m.Fields)
+	w.getRequestArgs(argsName, m)
+	if m.LongPolling {
+		w.line("func (p *%s) getTimeout() time.Duration {", argsName)
+		w.line("\tif p.Timeout > 0 {")
+		w.line("\t\treturn (time.Duration(p.Timeout) + Timeout) * time.Second")
+		w.line("\t}")
+		w.line("\treturn Timeout * time.Second")
+		w.line("}")
+		w.line("")
+	}
+	w.line("// %s#%s", spec.DocURL, strings.ToLower(m.Name))
+	if m.OptionalResult {
+		w.line("func (api *API) %s(args *%s) (*%s, error) {", name, argsName, m.Result)
+		w.line("\treturn callEdit(api, %q, args)", m.Name)
+	} else {
+		result := resultType(m.Result)
+		w.line("func (api *API) %s(args *%s) (%s, error) {", name, argsName, result)
+		w.line("\treturn Call[%s](api, %q, args)", result, m.Name)
+	}
+	w.line("}")
+	w.line("")
+}
+
+func header(w *writer, spec *Spec, imports ...string) {
+	w.line("// Code generated by tggen from botapi.json. DO NOT EDIT.")
+	w.line("")
+	w.line("package tg")
+	w.line("")
+	if len(imports) == 1 {
+		w.line("import %q", imports[0])
+		w.line("")
+	} else if len(imports) > 1 {
+		w.line("import (")
+		for _, imp := range imports {
+			w.line("\t%q", imp)
+		}
+		w.line(")")
+		w.line("")
+	}
+}
+
+func generateAPI(spec *Spec) ([]byte, error) {
+	w := &writer{}
+	header(w, spec, "time")
+	w.line("// %s Bot API %s", spec.DocURL, spec.Version)
+	w.line("const APIVersion = %q", spec.Version)
+	w.line("")
+	for _, m := range spec.Methods {
+		w.method(spec, m)
+	}
+	return format.Source(w.Bytes())
+}
+
+func generateTypes(spec *Spec) ([]byte, error) {
+	w := &writer{}
+	header(w, spec)
+	for _, t := range spec.Types {
+		w.constants(t.Constants)
+		w.line("// %s#%s", spec.DocURL, strings.ToLower(t.Name))
+		w.structure(t.Name, t.Fields)
+		w.getMedia(t)
+	}
+	return format.Source(w.Bytes())
+}
+
+func main() {
+	specFile := flag.String("spec", "botapi.json", "Bot API specification")
+	apiFile := flag.String("api", "api.go", "output file for methods")
+	typesFile := flag.String("types", "types.go", "output file for types")
+	flag.Parse()
+
+	data, err := ioutil.ReadFile(*specFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	spec := &Spec{}
+	if err = json.Unmarshal(data, spec); err != nil {
+		log.Fatal(err)
+	}
+	api, err := generateAPI(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	types, err := generateTypes(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile(*apiFile, api, 0644); err != nil {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile(*typesFile, types, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
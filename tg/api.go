@@ -1,138 +1,11 @@
-package tg
-
-import (
-	"bytes"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-	"strconv"
-	"time"
-)
-
-const Timeout = 15
-
-type HttpClient interface {
-	Do(url string, args *RequestArgs, timeout time.Duration) ([]byte, error)
-}
-
-type MethodArgs interface {
-	GetRequestArgs() (*RequestArgs, error)
-}
-
-type RequestArgs struct {
-	Body    *bytes.Buffer
-	Headers map[string]string
-}
+// Code generated by tggen from botapi.json. DO NOT EDIT.
 
-type DefaultHttpClient struct {
-}
+package tg
 
-func (c *DefaultHttpClient) Do(url string, args *RequestArgs, timeout time.Duration) ([]byte, error) {
-	client := &http.Client{Timeout: timeout}
-	request, _ := http.NewRequest("POST", url, args.Body)
-	for key, value := range args.Headers {
-		request.Header.Set(key, value)
-	}
-	response, err := client.Do(request)
-	if err != nil {
-		return nil, err
-	}
-	result, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
-	}
-	if err = response.Body.Close(); err != nil {
-		return nil, err
-	}
-	return result, nil
-}
+import "time"
 
 // https://core.telegram.org/bots/api Bot API 4.4
-type API struct {
-	Token  string
-	Client HttpClient
-}
-
-func (api *API) buildRequestArgs(args MethodArgs) (*RequestArgs, error) {
-	requestArgs, err := args.GetRequestArgs()
-	if err != nil {
-		return nil, err
-	}
-	return requestArgs, nil
-}
-
-func (api *API) buildURL(method string) string {
-	return fmt.Sprintf("https://api.telegram.org/bot%s/%s", api.Token, method)
-}
-
-func (api *API) sendRequest(url string, args *RequestArgs, timeout time.Duration) ([]byte, error) {
-	if api.Client != nil {
-		return api.Client.Do(url, args, timeout)
-	}
-	client := new(DefaultHttpClient)
-	return client.Do(url, args, timeout)
-
-}
-
-func (api *API) parseResponseBody(body []byte) (map[string]*json.RawMessage, error) {
-	var result map[string]*json.RawMessage
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
-	}
-	return result, nil
-}
-
-func (api *API) checkIfSuccess(result map[string]*json.RawMessage) error {
-	var ok bool
-	if err := json.Unmarshal(*result["ok"], &ok); err != nil {
-		return err
-	}
-	if ok == false {
-		var errorMessage string
-		if err := json.Unmarshal(*result["description"], &errorMessage); err != nil {
-			return err
-		}
-		return errors.New(errorMessage)
-	}
-	return nil
-}
-
-func (api *API) execute(method string, args MethodArgs, timeout time.Duration) (*json.RawMessage, error) {
-	url := api.buildURL(method)
-	requestArgs, err := api.buildRequestArgs(args)
-	if err != nil {
-		return nil, NewBuildRequestError(err.Error())
-	}
-	body, err := api.sendRequest(url, requestArgs, timeout)
-	if err != nil {
-		return nil, NewSendRequestError(err.Error())
-	}
-	result, err := api.parseResponseBody(body)
-	if err != nil {
-		return nil, NewParseResponseBodyError(err.Error())
-	}
-	if err = api.checkIfSuccess(result); err != nil {
-		return nil, NewAPIError(err.Error())
-	}
-	return result["result"], nil
-}
-
-type ChatID struct {
-	ID       int
-	Username string
-}
-
-func (cid *ChatID) MarshalJSON() ([]byte, error) {
-	var value string
-	if cid.ID != 0 {
-		value = strconv.Itoa(cid.ID)
-	} else {
-		value = cid.Username
-	}
-	return []byte(value), nil
-}
+const APIVersion = "4.4"
 
 const (
 	AllowedUpdateMessage            = "message"
@@ -155,123 +28,96 @@ type GetUpdatesArgs struct {
 }
 
 func (p *GetUpdatesArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
 }
 
-// https://core.telegram.org/bots/api#getupdates
-func (api *API) GetUpdates(args *GetUpdatesArgs) (*[]*Update, error) {
-	var update *[]*Update
-	method := "getUpdates"
-	var timeout time.Duration
-	if args.Timeout > 0 {
-		timeout = (time.Duration(args.Timeout) + Timeout) * time.Second
-	} else {
-		timeout = Timeout * time.Second
-	}
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &update); err != nil {
-		return nil, err
+func (p *GetUpdatesArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
+}
+
+func (p *GetUpdatesArgs) getTimeout() time.Duration {
+	if p.Timeout > 0 {
+		return (time.Duration(p.Timeout) + Timeout) * time.Second
 	}
-	return update, nil
+	return Timeout * time.Second
+}
+
+// https://core.telegram.org/bots/api#getupdates
+func (api *API) GetUpdates(args *GetUpdatesArgs) ([]*Update, error) {
+	return Call[[]*Update](api, "getUpdates", args)
 }
 
 type SetWebhookArgs struct {
 	URL               string     `json:"url"`
 	MaxConnections    int        `json:"max_connections,omitempty"`
 	AllowedUpdates    []string   `json:"allowed_updates,omitempty"`
+	SecretToken       string     `json:"secret_token,omitempty"` // sent back in the X-Telegram-Bot-Api-Secret-Token header
 	CertificateAsFile *InputFile `json:"-"`
 }
 
 func (p *SetWebhookArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SetWebhookArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.CertificateAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		return buildMultipartRequestArgs(args, []*InputFile{p.CertificateAsFile})
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#setwebhook
-func (api *API) SetWebhook(args *SetWebhookArgs) (*bool, error) {
-	var success *bool
-	method := "setWebhook"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) SetWebhook(args *SetWebhookArgs) (bool, error) {
+	return Call[bool](api, "setWebhook", args)
 }
 
 type DeleteWebhookArgs struct {
 }
 
 func (p *DeleteWebhookArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *DeleteWebhookArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#deletewebhook
-func (api *API) DeleteWebhook(args *DeleteWebhookArgs) (*bool, error) {
-	var success *bool
-	method := "deleteWebhook"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) DeleteWebhook(args *DeleteWebhookArgs) (bool, error) {
+	return Call[bool](api, "deleteWebhook", args)
 }
 
 type GetWebhookInfoArgs struct {
 }
 
 func (p *GetWebhookInfoArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *GetWebhookInfoArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#getwebhookinfo
 func (api *API) GetWebhookInfo(args *GetWebhookInfoArgs) (*WebhookInfo, error) {
-	var webhookInfo *WebhookInfo
-	method := "getWebhookInfo"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &webhookInfo); err != nil {
-		return nil, err
-	}
-	return webhookInfo, nil
+	return Call[*WebhookInfo](api, "getWebhookInfo", args)
 }
 
 type GetMeArgs struct {
 }
 
 func (p *GetMeArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *GetMeArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#getme
 func (api *API) GetMe(args *GetMeArgs) (*User, error) {
-	var user *User
-	method := "getMe"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &user); err != nil {
-		return nil, err
-	}
-	return user, nil
+	return Call[*User](api, "getMe", args)
 }
 
 const (
@@ -290,22 +136,16 @@ type SendMessageArgs struct {
 }
 
 func (p *SendMessageArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendMessageArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendmessage
 func (api *API) SendMessage(args *SendMessageArgs) (*Message, error) {
-	var message *Message
-	method := "sendMessage"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendMessage", args)
 }
 
 type ForwardMessageArgs struct {
@@ -316,22 +156,16 @@ type ForwardMessageArgs struct {
 }
 
 func (p *ForwardMessageArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *ForwardMessageArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#forwardmessage
 func (api *API) ForwardMessage(args *ForwardMessageArgs) (*Message, error) {
-	var message *Message
-	method := "forwardMessage"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "forwardMessage", args)
 }
 
 type SendPhotoArgs struct {
@@ -346,27 +180,20 @@ type SendPhotoArgs struct {
 }
 
 func (p *SendPhotoArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendPhotoArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.PhotoAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		return buildMultipartRequestArgs(args, []*InputFile{p.PhotoAsFile})
 	}
-	return buildJSONRequestArgs(p)
-
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendphoto
 func (api *API) SendPhoto(args *SendPhotoArgs) (*Message, error) {
-	var message *Message
-	method := "sendPhoto"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendPhoto", args)
 }
 
 type SendAudioArgs struct {
@@ -386,8 +213,12 @@ type SendAudioArgs struct {
 }
 
 func (p *SendAudioArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendAudioArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.AudioAsFile.isAllSet() || p.ThumbAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		var files []*InputFile
 		if p.AudioAsFile.isAllSet() {
 			files = append(files, p.AudioAsFile)
@@ -397,22 +228,12 @@ func (p *SendAudioArgs) GetRequestArgs() (*RequestArgs, error) {
 		}
 		return buildMultipartRequestArgs(args, files)
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendaudio
 func (api *API) SendAudio(args *SendAudioArgs) (*Message, error) {
-	var message *Message
-	method := "sendAudio"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendAudio", args)
 }
 
 type SendDocumentArgs struct {
@@ -429,8 +250,12 @@ type SendDocumentArgs struct {
 }
 
 func (p *SendDocumentArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendDocumentArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.DocumentAsFile.isAllSet() || p.ThumbAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		var files []*InputFile
 		if p.DocumentAsFile.isAllSet() {
 			files = append(files, p.DocumentAsFile)
@@ -440,22 +265,12 @@ func (p *SendDocumentArgs) GetRequestArgs() (*RequestArgs, error) {
 		}
 		return buildMultipartRequestArgs(args, files)
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#senddocument
 func (api *API) SendDocument(args *SendDocumentArgs) (*Message, error) {
-	var message *Message
-	method := "sendDocument"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendDocument", args)
 }
 
 type SendVideoArgs struct {
@@ -476,8 +291,12 @@ type SendVideoArgs struct {
 }
 
 func (p *SendVideoArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendVideoArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.VideoAsFile.isAllSet() || p.ThumbAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		var files []*InputFile
 		if p.VideoAsFile.isAllSet() {
 			files = append(files, p.VideoAsFile)
@@ -487,22 +306,12 @@ func (p *SendVideoArgs) GetRequestArgs() (*RequestArgs, error) {
 		}
 		return buildMultipartRequestArgs(args, files)
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendvideo
 func (api *API) SendVideo(args *SendVideoArgs) (*Message, error) {
-	var message *Message
-	method := "sendVideo"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendVideo", args)
 }
 
 type SendAnimationArgs struct {
@@ -522,8 +331,12 @@ type SendAnimationArgs struct {
 }
 
 func (p *SendAnimationArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendAnimationArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.AnimationAsFile.isAllSet() || p.ThumbAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		var files []*InputFile
 		if p.AnimationAsFile.isAllSet() {
 			files = append(files, p.AnimationAsFile)
@@ -533,22 +346,12 @@ func (p *SendAnimationArgs) GetRequestArgs() (*RequestArgs, error) {
 		}
 		return buildMultipartRequestArgs(args, files)
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendanimation
 func (api *API) SendAnimation(args *SendAnimationArgs) (*Message, error) {
-	var message *Message
-	method := "sendAnimation"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendAnimation", args)
 }
 
 type SendVoiceArgs struct {
@@ -564,26 +367,20 @@ type SendVoiceArgs struct {
 }
 
 func (p *SendVoiceArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendVoiceArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.VoiceAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		return buildMultipartRequestArgs(args, []*InputFile{p.VoiceAsFile})
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendvoice
 func (api *API) SendVoice(args *SendVoiceArgs) (*Message, error) {
-	var message *Message
-	method := "sendVoice"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendVoice", args)
 }
 
 type SendVideoNoteArgs struct {
@@ -600,8 +397,12 @@ type SendVideoNoteArgs struct {
 }
 
 func (p *SendVideoNoteArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendVideoNoteArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.VideoNoteAsFile.isAllSet() || p.ThumbAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		var files []*InputFile
 		if p.VideoNoteAsFile.isAllSet() {
 			files = append(files, p.VideoNoteAsFile)
@@ -611,22 +412,12 @@ func (p *SendVideoNoteArgs) GetRequestArgs() (*RequestArgs, error) {
 		}
 		return buildMultipartRequestArgs(args, files)
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
-// https://core.telegram.org/bots/api#sendvideoNote
+// https://core.telegram.org/bots/api#sendvideonote
 func (api *API) SendVideoNote(args *SendVideoNoteArgs) (*Message, error) {
-	var message *Message
-	method := "sendVideoNote"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendVideoNote", args)
 }
 
 type SendMediaGroupArgs struct {
@@ -637,6 +428,10 @@ type SendMediaGroupArgs struct {
 }
 
 func (p *SendMediaGroupArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendMediaGroupArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	var files []*InputFile
 	for _, media := range p.Media {
 		for _, file := range media.getMedia() {
@@ -646,25 +441,15 @@ func (p *SendMediaGroupArgs) GetRequestArgs() (*RequestArgs, error) {
 		}
 	}
 	if len(files) > 0 {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		return buildMultipartRequestArgs(args, files)
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendmediagroup
-func (api *API) SendMediaGroup(args *SendMediaGroupArgs) (*[]*Message, error) {
-	var messages *[]*Message
-	method := "sendMediaGroup"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &messages); err != nil {
-		return nil, err
-	}
-	return messages, nil
+func (api *API) SendMediaGroup(args *SendMediaGroupArgs) ([]*Message, error) {
+	return Call[[]*Message](api, "sendMediaGroup", args)
 }
 
 type SendLocationArgs struct {
@@ -678,68 +463,60 @@ type SendLocationArgs struct {
 }
 
 func (p *SendLocationArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendLocationArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendlocation
 func (api *API) SendLocation(args *SendLocationArgs) (*Message, error) {
-	var message *Message
-	method := "sendLocation"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendLocation", args)
 }
 
 type EditMessageLiveLocationArgs struct {
-	ChatID          *ChatID               `json:"chat_id,omitempty"`
-	MessageID       int                   `json:"message_id,omitempty"`
-	InlineMessageID string                `json:"inline_message_id,omitempty"`
-	Latitude        float64               `json:"latitude"`
-	Longitude       float64               `json:"longitude"`
-	ReplyMarkup     *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	*MessageRef
+	Latitude    float64               `json:"latitude"`
+	Longitude   float64               `json:"longitude"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
 }
 
 func (p *EditMessageLiveLocationArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
 }
 
-// https://core.telegram.org/bots/api#editmessagelivelocation
-func (api *API) EditMessageLiveLocation(args *EditMessageLiveLocationArgs) (*OptionalMessage, error) {
-	method := "editMessageLiveLocation"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
+func (p *EditMessageLiveLocationArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	if err := p.MessageRef.validate(); err != nil {
 		return nil, err
 	}
-	return buildOptionalMessage(response)
+	return buildJSONRequestArgs(codec, p)
+}
+
+// https://core.telegram.org/bots/api#editmessagelivelocation
+func (api *API) EditMessageLiveLocation(args *EditMessageLiveLocationArgs) (*Message, error) {
+	return callEdit(api, "editMessageLiveLocation", args)
 }
 
 type StopMessageLiveLocationArgs struct {
-	ChatID          *ChatID               `json:"chat_id,omitempty"`
-	MessageID       int                   `json:"message_id,omitempty"`
-	InlineMessageID string                `json:"inline_message_id,omitempty"`
-	ReplyMarkup     *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	*MessageRef
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
 }
 
 func (p *StopMessageLiveLocationArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
 }
 
-// https://core.telegram.org/bots/api#stopmessagelivelocation
-func (api *API) StopMessageLiveLocation(args *StopMessageLiveLocationArgs) (*OptionalMessage, error) {
-	method := "stopMessageLiveLocation"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
+func (p *StopMessageLiveLocationArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	if err := p.MessageRef.validate(); err != nil {
 		return nil, err
 	}
-	return buildOptionalMessage(response)
+	return buildJSONRequestArgs(codec, p)
+}
+
+// https://core.telegram.org/bots/api#stopmessagelivelocation
+func (api *API) StopMessageLiveLocation(args *StopMessageLiveLocationArgs) (*Message, error) {
+	return callEdit(api, "stopMessageLiveLocation", args)
 }
 
 type SendVenueArgs struct {
@@ -756,22 +533,16 @@ type SendVenueArgs struct {
 }
 
 func (p *SendVenueArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendVenueArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendvenue
 func (api *API) SendVenue(args *SendVenueArgs) (*Message, error) {
-	var message *Message
-	method := "sendVenue"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendVenue", args)
 }
 
 type SendContactArgs struct {
@@ -786,22 +557,16 @@ type SendContactArgs struct {
 }
 
 func (p *SendContactArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendContactArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendcontact
 func (api *API) SendContact(args *SendContactArgs) (*Message, error) {
-	var message *Message
-	method := "sendContact"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendContact", args)
 }
 
 type SendPollArgs struct {
@@ -814,22 +579,16 @@ type SendPollArgs struct {
 }
 
 func (p *SendPollArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendPollArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendpoll
 func (api *API) SendPoll(args *SendPollArgs) (*Message, error) {
-	var message *Message
-	method := "sendPoll"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendPoll", args)
 }
 
 const (
@@ -851,22 +610,16 @@ type SendChatActionArgs struct {
 }
 
 func (p *SendChatActionArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendChatActionArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendchataction
-func (api *API) SendChatAction(args *SendChatActionArgs) (*bool, error) {
-	var success *bool
-	method := "sendChatAction"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) SendChatAction(args *SendChatActionArgs) (bool, error) {
+	return Call[bool](api, "sendChatAction", args)
 }
 
 type GetUserProfilePhotosArgs struct {
@@ -876,22 +629,16 @@ type GetUserProfilePhotosArgs struct {
 }
 
 func (p *GetUserProfilePhotosArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *GetUserProfilePhotosArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#getuserprofilephotos
 func (api *API) GetUserProfilePhotos(args *GetUserProfilePhotosArgs) (*UserProfilePhotos, error) {
-	var userProfilePhotos *UserProfilePhotos
-	method := "getUserProfilePhotos"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &userProfilePhotos); err != nil {
-		return nil, err
-	}
-	return userProfilePhotos, nil
+	return Call[*UserProfilePhotos](api, "getUserProfilePhotos", args)
 }
 
 type GetFileArgs struct {
@@ -899,22 +646,16 @@ type GetFileArgs struct {
 }
 
 func (p *GetFileArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *GetFileArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#getfile
 func (api *API) GetFile(args *GetFileArgs) (*File, error) {
-	var file *File
-	method := "getFile"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &file); err != nil {
-		return nil, err
-	}
-	return file, nil
+	return Call[*File](api, "getFile", args)
 }
 
 type KickChatMemberArgs struct {
@@ -924,22 +665,16 @@ type KickChatMemberArgs struct {
 }
 
 func (p *KickChatMemberArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *KickChatMemberArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#kickchatmember
-func (api *API) KickChatMember(args *KickChatMemberArgs) (*bool, error) {
-	var success *bool
-	method := "kickChatMember"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) KickChatMember(args *KickChatMemberArgs) (bool, error) {
+	return Call[bool](api, "kickChatMember", args)
 }
 
 type UnbanChatMemberArgs struct {
@@ -948,22 +683,16 @@ type UnbanChatMemberArgs struct {
 }
 
 func (p *UnbanChatMemberArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *UnbanChatMemberArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#unbanchatmember
-func (api *API) UnbanChatMember(args *UnbanChatMemberArgs) (*bool, error) {
-	var success *bool
-	method := "unbanChatMember"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) UnbanChatMember(args *UnbanChatMemberArgs) (bool, error) {
+	return Call[bool](api, "unbanChatMember", args)
 }
 
 type RestrictChatMemberArgs struct {
@@ -974,22 +703,16 @@ type RestrictChatMemberArgs struct {
 }
 
 func (p *RestrictChatMemberArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *RestrictChatMemberArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#restrictchatmember
-func (api *API) RestrictChatMember(args *RestrictChatMemberArgs) (*bool, error) {
-	var success *bool
-	method := "restrictChatMember"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) RestrictChatMember(args *RestrictChatMemberArgs) (bool, error) {
+	return Call[bool](api, "restrictChatMember", args)
 }
 
 type PromoteChatMemberArgs struct {
@@ -1006,22 +729,16 @@ type PromoteChatMemberArgs struct {
 }
 
 func (p *PromoteChatMemberArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *PromoteChatMemberArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#promotechatmember
-func (api *API) PromoteChatMember(args *PromoteChatMemberArgs) (*bool, error) {
-	var success *bool
-	method := "promoteChatMember"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) PromoteChatMember(args *PromoteChatMemberArgs) (bool, error) {
+	return Call[bool](api, "promoteChatMember", args)
 }
 
 type SetChatPermissionsArgs struct {
@@ -1030,22 +747,16 @@ type SetChatPermissionsArgs struct {
 }
 
 func (p *SetChatPermissionsArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SetChatPermissionsArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#setchatpermissions
-func (api *API) SetChatPermissions(args *SetChatPermissionsArgs) (*bool, error) {
-	var success *bool
-	method := "setChatPermissions"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) SetChatPermissions(args *SetChatPermissionsArgs) (bool, error) {
+	return Call[bool](api, "setChatPermissions", args)
 }
 
 type ExportChatInviteLinkArgs struct {
@@ -1053,22 +764,16 @@ type ExportChatInviteLinkArgs struct {
 }
 
 func (p *ExportChatInviteLinkArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *ExportChatInviteLinkArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#exportchatinvitelink
-func (api *API) ExportChatInviteLink(args *ExportChatInviteLinkArgs) (*string, error) {
-	var inviteLink *string
-	method := "exportChatInviteLink"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &inviteLink); err != nil {
-		return nil, err
-	}
-	return inviteLink, nil
+func (api *API) ExportChatInviteLink(args *ExportChatInviteLinkArgs) (string, error) {
+	return Call[string](api, "exportChatInviteLink", args)
 }
 
 type SetChatPhotoArgs struct {
@@ -1078,26 +783,20 @@ type SetChatPhotoArgs struct {
 }
 
 func (p *SetChatPhotoArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SetChatPhotoArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.PhotoAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		return buildMultipartRequestArgs(args, []*InputFile{p.PhotoAsFile})
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#setchatphoto
-func (api *API) SetChatPhoto(args *SetChatPhotoArgs) (*bool, error) {
-	var success *bool
-	method := "setChatPhoto"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) SetChatPhoto(args *SetChatPhotoArgs) (bool, error) {
+	return Call[bool](api, "setChatPhoto", args)
 }
 
 type DeleteChatPhotoArgs struct {
@@ -1105,22 +804,16 @@ type DeleteChatPhotoArgs struct {
 }
 
 func (p *DeleteChatPhotoArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
 }
 
-// https://core.telegram.org/bots/api#deletechatPhoto
-func (api *API) DeleteChatPhoto(args *DeleteChatPhotoArgs) (*bool, error) {
-	var success *bool
-	method := "deleteChatPhoto"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (p *DeleteChatPhotoArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
+}
+
+// https://core.telegram.org/bots/api#deletechatphoto
+func (api *API) DeleteChatPhoto(args *DeleteChatPhotoArgs) (bool, error) {
+	return Call[bool](api, "deleteChatPhoto", args)
 }
 
 type SetChatTitleArgs struct {
@@ -1129,22 +822,16 @@ type SetChatTitleArgs struct {
 }
 
 func (p *SetChatTitleArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SetChatTitleArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#setchattitle
-func (api *API) SetChatTitle(args *SetChatTitleArgs) (*bool, error) {
-	var success *bool
-	method := "setChatTitle"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) SetChatTitle(args *SetChatTitleArgs) (bool, error) {
+	return Call[bool](api, "setChatTitle", args)
 }
 
 type SetChatDescriptionArgs struct {
@@ -1153,22 +840,16 @@ type SetChatDescriptionArgs struct {
 }
 
 func (p *SetChatDescriptionArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SetChatDescriptionArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#setchatdescription
-func (api *API) SetChatDescription(args *SetChatDescriptionArgs) (*bool, error) {
-	var success *bool
-	method := "setChatDescription"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) SetChatDescription(args *SetChatDescriptionArgs) (bool, error) {
+	return Call[bool](api, "setChatDescription", args)
 }
 
 type PinChatMessageArgs struct {
@@ -1178,22 +859,16 @@ type PinChatMessageArgs struct {
 }
 
 func (p *PinChatMessageArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *PinChatMessageArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#pinchatmessage
-func (api *API) PinChatMessage(args *PinChatMessageArgs) (*bool, error) {
-	var success *bool
-	method := "pinChatMessage"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) PinChatMessage(args *PinChatMessageArgs) (bool, error) {
+	return Call[bool](api, "pinChatMessage", args)
 }
 
 type UnpinChatMessageArgs struct {
@@ -1201,22 +876,16 @@ type UnpinChatMessageArgs struct {
 }
 
 func (p *UnpinChatMessageArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *UnpinChatMessageArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#unpinchatmessage
-func (api *API) UnpinChatMessage(args *UnpinChatMessageArgs) (*bool, error) {
-	var success *bool
-	method := "unpinChatMessage"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) UnpinChatMessage(args *UnpinChatMessageArgs) (bool, error) {
+	return Call[bool](api, "unpinChatMessage", args)
 }
 
 type LeaveChatArgs struct {
@@ -1224,22 +893,16 @@ type LeaveChatArgs struct {
 }
 
 func (p *LeaveChatArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *LeaveChatArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#leavechat
-func (api *API) LeaveChat(args *LeaveChatArgs) (*bool, error) {
-	var success *bool
-	method := "leaveChat"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) LeaveChat(args *LeaveChatArgs) (bool, error) {
+	return Call[bool](api, "leaveChat", args)
 }
 
 type GetChatArgs struct {
@@ -1247,22 +910,16 @@ type GetChatArgs struct {
 }
 
 func (p *GetChatArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *GetChatArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#getchat
 func (api *API) GetChat(args *GetChatArgs) (*Chat, error) {
-	var chat *Chat
-	method := "getChat"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &chat); err != nil {
-		return nil, err
-	}
-	return chat, nil
+	return Call[*Chat](api, "getChat", args)
 }
 
 type GetChatAdministratorsArgs struct {
@@ -1270,22 +927,16 @@ type GetChatAdministratorsArgs struct {
 }
 
 func (p *GetChatAdministratorsArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *GetChatAdministratorsArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#getchatadministrators
-func (api *API) GetChatAdministrators(args *GetChatAdministratorsArgs) (*[]*ChatMember, error) {
-	var chatMembers *[]*ChatMember
-	method := "getChatAdministrators"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &chatMembers); err != nil {
-		return nil, err
-	}
-	return chatMembers, nil
+func (api *API) GetChatAdministrators(args *GetChatAdministratorsArgs) ([]*ChatMember, error) {
+	return Call[[]*ChatMember](api, "getChatAdministrators", args)
 }
 
 type GetChatMembersCountArgs struct {
@@ -1293,22 +944,16 @@ type GetChatMembersCountArgs struct {
 }
 
 func (p *GetChatMembersCountArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *GetChatMembersCountArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#getchatmemberscount
-func (api *API) GetChatMembersCount(args *GetChatMembersCountArgs) (*int, error) {
-	var count *int
-	method := "getChatMembersCount"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &count); err != nil {
-		return nil, err
-	}
-	return count, nil
+func (api *API) GetChatMembersCount(args *GetChatMembersCountArgs) (int, error) {
+	return Call[int](api, "getChatMembersCount", args)
 }
 
 type GetChatMemberArgs struct {
@@ -1317,22 +962,16 @@ type GetChatMemberArgs struct {
 }
 
 func (p *GetChatMemberArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *GetChatMemberArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#getchatmember
 func (api *API) GetChatMember(args *GetChatMemberArgs) (*ChatMember, error) {
-	var chatMember *ChatMember
-	method := "getChatMember"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &chatMember); err != nil {
-		return nil, err
-	}
-	return chatMember, nil
+	return Call[*ChatMember](api, "getChatMember", args)
 }
 
 type SetChatStickerSetArgs struct {
@@ -1341,22 +980,16 @@ type SetChatStickerSetArgs struct {
 }
 
 func (p *SetChatStickerSetArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SetChatStickerSetArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#setchatstickerset
-func (api *API) SetChatStickerSet(args *SetChatStickerSetArgs) (*bool, error) {
-	var success *bool
-	method := "setChatStickerSet"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) SetChatStickerSet(args *SetChatStickerSetArgs) (bool, error) {
+	return Call[bool](api, "setChatStickerSet", args)
 }
 
 type DeleteChatStickerSetArgs struct {
@@ -1364,22 +997,16 @@ type DeleteChatStickerSetArgs struct {
 }
 
 func (p *DeleteChatStickerSetArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *DeleteChatStickerSetArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#deletechatstickerset
-func (api *API) DeleteChatStickerSet(args *DeleteChatStickerSetArgs) (*bool, error) {
-	var success *bool
-	method := "deleteChatStickerSet"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) DeleteChatStickerSet(args *DeleteChatStickerSetArgs) (bool, error) {
+	return Call[bool](api, "deleteChatStickerSet", args)
 }
 
 type AnswerCallbackQueryArgs struct {
@@ -1391,28 +1018,20 @@ type AnswerCallbackQueryArgs struct {
 }
 
 func (p *AnswerCallbackQueryArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *AnswerCallbackQueryArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#answercallbackquery
-func (api *API) AnswerCallbackQuery(args *AnswerCallbackQueryArgs) (*bool, error) {
-	var success *bool
-	method := "answerCallbackQuery"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) AnswerCallbackQuery(args *AnswerCallbackQueryArgs) (bool, error) {
+	return Call[bool](api, "answerCallbackQuery", args)
 }
 
 type EditMessageTextArgs struct {
-	ChatID                *ChatID               `json:"chat_id,omitempty"`
-	MessageID             int                   `json:"message_id,omitempty"`
-	InlineMessageID       string                `json:"inline_message_id,omitempty"`
+	*MessageRef
 	Text                  string                `json:"text"`
 	ParseMode             string                `json:"parse_mode,omitempty"`
 	DisableWebPagePreview bool                  `json:"disable_web_page_preview,omitempty"`
@@ -1420,98 +1039,98 @@ type EditMessageTextArgs struct {
 }
 
 func (p *EditMessageTextArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
 }
 
-// https://core.telegram.org/bots/api#editmessagetext
-func (api *API) EditMessageText(args *EditMessageTextArgs) (*OptionalMessage, error) {
-	method := "editMessageText"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
+func (p *EditMessageTextArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	if err := p.MessageRef.validate(); err != nil {
 		return nil, err
 	}
-	return buildOptionalMessage(response)
+	return buildJSONRequestArgs(codec, p)
+}
+
+// https://core.telegram.org/bots/api#editmessagetext
+func (api *API) EditMessageText(args *EditMessageTextArgs) (*Message, error) {
+	return callEdit(api, "editMessageText", args)
 }
 
 type EditMessageCaptionArgs struct {
-	ChatID          *ChatID               `json:"chat_id,omitempty"`
-	MessageID       int                   `json:"message_id,omitempty"`
-	InlineMessageID string                `json:"inline_message_id,omitempty"`
-	Caption         string                `json:"caption,omitempty"`
-	ParseMode       string                `json:"parse_mode,omitempty"`
-	ReplyMarkup     *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	*MessageRef
+	Caption     string                `json:"caption,omitempty"`
+	ParseMode   string                `json:"parse_mode,omitempty"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
 }
 
 func (p *EditMessageCaptionArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
 }
 
-// https://core.telegram.org/bots/api#editmessagecaption
-func (api *API) EditMessageCaption(args *EditMessageCaptionArgs) (*OptionalMessage, error) {
-	method := "editMessageCaption"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
+func (p *EditMessageCaptionArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	if err := p.MessageRef.validate(); err != nil {
 		return nil, err
 	}
-	return buildOptionalMessage(response)
+	return buildJSONRequestArgs(codec, p)
+}
+
+// https://core.telegram.org/bots/api#editmessagecaption
+func (api *API) EditMessageCaption(args *EditMessageCaptionArgs) (*Message, error) {
+	return callEdit(api, "editMessageCaption", args)
 }
 
 type EditMessageMediaArgs struct {
-	ChatID          *ChatID               `json:"chat_id,omitempty"`
-	MessageID       int                   `json:"message_id,omitempty"`
-	InlineMessageID string                `json:"inline_message_id,omitempty"`
-	Media           *InputMedia           `json:"media"`
-	ReplyMarkup     *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	*MessageRef
+	Media       *InputMedia           `json:"media"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
 }
 
 func (p *EditMessageMediaArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *EditMessageMediaArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	if err := p.MessageRef.validate(); err != nil {
+		return nil, err
+	}
 	var files []*InputFile
-	media := *p.Media
-	for _, file := range media.getMedia() {
-		if file.isAllSet() {
-			files = append(files, file)
+	if p.Media != nil && *p.Media != nil {
+		media := *p.Media
+		for _, file := range media.getMedia() {
+			if file.isAllSet() {
+				files = append(files, file)
+			}
 		}
 	}
 	if len(files) > 0 {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		return buildMultipartRequestArgs(args, files)
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#editmessagemedia
-func (api *API) EditMessageMedia(args *EditMessageMediaArgs) (*OptionalMessage, error) {
-	method := "editMessageMedia"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	return buildOptionalMessage(response)
+func (api *API) EditMessageMedia(args *EditMessageMediaArgs) (*Message, error) {
+	return callEdit(api, "editMessageMedia", args)
 }
 
 type EditMessageReplyMarkupArgs struct {
-	ChatID          *ChatID               `json:"chat_id,omitempty"`
-	MessageID       int                   `json:"message_id,omitempty"`
-	InlineMessageID string                `json:"inline_message_id,omitempty"`
-	ReplyMarkup     *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	*MessageRef
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
 }
 
 func (p *EditMessageReplyMarkupArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
 }
 
-// https://core.telegram.org/bots/api#editmessagereplymarkup
-func (api *API) EditMessageReplyMarkup(args *EditMessageReplyMarkupArgs) (*OptionalMessage, error) {
-	method := "editMessageReplyMarkup"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
+func (p *EditMessageReplyMarkupArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	if err := p.MessageRef.validate(); err != nil {
 		return nil, err
 	}
-	return buildOptionalMessage(response)
+	return buildJSONRequestArgs(codec, p)
+}
+
+// https://core.telegram.org/bots/api#editmessagereplymarkup
+func (api *API) EditMessageReplyMarkup(args *EditMessageReplyMarkupArgs) (*Message, error) {
+	return callEdit(api, "editMessageReplyMarkup", args)
 }
 
 type StopPollArgs struct {
@@ -1521,22 +1140,16 @@ type StopPollArgs struct {
 }
 
 func (p *StopPollArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *StopPollArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#stoppoll
 func (api *API) StopPoll(args *StopPollArgs) (*Poll, error) {
-	var poll *Poll
-	method := "stopPoll"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &poll); err != nil {
-		return nil, err
-	}
-	return poll, nil
+	return Call[*Poll](api, "stopPoll", args)
 }
 
 type DeleteMessageArgs struct {
@@ -1545,22 +1158,16 @@ type DeleteMessageArgs struct {
 }
 
 func (p *DeleteMessageArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *DeleteMessageArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#deletemessage
-func (api *API) DeleteMessage(args *DeleteMessageArgs) (*bool, error) {
-	var success *bool
-	method := "deleteMessage"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) DeleteMessage(args *DeleteMessageArgs) (bool, error) {
+	return Call[bool](api, "deleteMessage", args)
 }
 
 type SendStickerArgs struct {
@@ -1573,26 +1180,20 @@ type SendStickerArgs struct {
 }
 
 func (p *SendStickerArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendStickerArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.StickerAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		return buildMultipartRequestArgs(args, []*InputFile{p.StickerAsFile})
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendsticker
 func (api *API) SendSticker(args *SendStickerArgs) (*Message, error) {
-	var message *Message
-	method := "sendSticker"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendSticker", args)
 }
 
 type GetStickerSetArgs struct {
@@ -1600,22 +1201,16 @@ type GetStickerSetArgs struct {
 }
 
 func (p *GetStickerSetArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *GetStickerSetArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#getstickerset
 func (api *API) GetStickerSet(args *GetStickerSetArgs) (*StickerSet, error) {
-	var stickerSet *StickerSet
-	method := "getStickerSet"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &stickerSet); err != nil {
-		return nil, err
-	}
-	return stickerSet, nil
+	return Call[*StickerSet](api, "getStickerSet", args)
 }
 
 type UploadStickerFileArgs struct {
@@ -1625,26 +1220,20 @@ type UploadStickerFileArgs struct {
 }
 
 func (p *UploadStickerFileArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *UploadStickerFileArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.PngStickerAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		return buildMultipartRequestArgs(args, []*InputFile{p.PngStickerAsFile})
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#uploadstickerfile
 func (api *API) UploadStickerFile(args *UploadStickerFileArgs) (*File, error) {
-	var file *File
-	method := "uploadStickerFile"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &file); err != nil {
-		return nil, err
-	}
-	return file, nil
+	return Call[*File](api, "uploadStickerFile", args)
 }
 
 type CreateNewStickerSetArgs struct {
@@ -1659,26 +1248,20 @@ type CreateNewStickerSetArgs struct {
 }
 
 func (p *CreateNewStickerSetArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *CreateNewStickerSetArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.PngStickerAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		return buildMultipartRequestArgs(args, []*InputFile{p.PngStickerAsFile})
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#createnewstickerset
-func (api *API) CreateNewStickerSet(args *CreateNewStickerSetArgs) (*bool, error) {
-	var success *bool
-	method := "createNewStickerSet"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) CreateNewStickerSet(args *CreateNewStickerSetArgs) (bool, error) {
+	return Call[bool](api, "createNewStickerSet", args)
 }
 
 type AddStickerToSetArgs struct {
@@ -1691,26 +1274,20 @@ type AddStickerToSetArgs struct {
 }
 
 func (p *AddStickerToSetArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *AddStickerToSetArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
 	if p.PngStickerAsFile.isAllSet() {
-		args := marshallToMap(p)
+		args := marshallToMap(codec, p)
 		return buildMultipartRequestArgs(args, []*InputFile{p.PngStickerAsFile})
 	}
-	return buildJSONRequestArgs(p)
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#addstickertoset
-func (api *API) AddStickerToSet(args *AddStickerToSetArgs) (*bool, error) {
-	var success *bool
-	method := "addStickerToSet"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) AddStickerToSet(args *AddStickerToSetArgs) (bool, error) {
+	return Call[bool](api, "addStickerToSet", args)
 }
 
 type SetStickerPositionInSetArgs struct {
@@ -1719,22 +1296,16 @@ type SetStickerPositionInSetArgs struct {
 }
 
 func (p *SetStickerPositionInSetArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SetStickerPositionInSetArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#setstickerpositioninset
-func (api *API) SetStickerPositionInSet(args *SetStickerPositionInSetArgs) (*bool, error) {
-	var success *bool
-	method := "setStickerPositionInSet"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) SetStickerPositionInSet(args *SetStickerPositionInSetArgs) (bool, error) {
+	return Call[bool](api, "setStickerPositionInSet", args)
 }
 
 type DeleteStickerFromSetArgs struct {
@@ -1742,22 +1313,16 @@ type DeleteStickerFromSetArgs struct {
 }
 
 func (p *DeleteStickerFromSetArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *DeleteStickerFromSetArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#deletestickerfromset
-func (api *API) DeleteStickerFromSet(args *DeleteStickerFromSetArgs) (*bool, error) {
-	var success *bool
-	method := "deleteStickerFromSet"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) DeleteStickerFromSet(args *DeleteStickerFromSetArgs) (bool, error) {
+	return Call[bool](api, "deleteStickerFromSet", args)
 }
 
 type AnswerInlineQueryArgs struct {
@@ -1771,22 +1336,16 @@ type AnswerInlineQueryArgs struct {
 }
 
 func (p *AnswerInlineQueryArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *AnswerInlineQueryArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#answerinlinequery
-func (api *API) AnswerInlineQuery(args *AnswerInlineQueryArgs) (*bool, error) {
-	var success *bool
-	method := "answerInlineQuery"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) AnswerInlineQuery(args *AnswerInlineQueryArgs) (bool, error) {
+	return Call[bool](api, "answerInlineQuery", args)
 }
 
 type SendInvoiceArgs struct {
@@ -1816,22 +1375,19 @@ type SendInvoiceArgs struct {
 }
 
 func (p *SendInvoiceArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
 }
 
-// https://core.telegram.org/bots/api#sendinvoice
-func (api *API) SendInvoice(args *SendInvoiceArgs) (*Message, error) {
-	var message *Message
-	method := "sendInvoice"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
+func (p *SendInvoiceArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	if err := p.validate(); err != nil {
 		return nil, err
 	}
-	return message, nil
+	return buildJSONRequestArgs(codec, p)
+}
+
+// https://core.telegram.org/bots/api#sendinvoice
+func (api *API) SendInvoice(args *SendInvoiceArgs) (*Message, error) {
+	return Call[*Message](api, "sendInvoice", args)
 }
 
 type AnswerShippingQueryArgs struct {
@@ -1839,25 +1395,23 @@ type AnswerShippingQueryArgs struct {
 	Ok              bool              `json:"ok"`
 	ShippingOptions []*ShippingOption `json:"shipping_options,omitempty"`
 	ErrorMessage    string            `json:"error_message,omitempty"`
+	Currency        string            `json:"-"` // Optional, checks option totals against the currency limits
 }
 
 func (p *AnswerShippingQueryArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
 }
 
-// https://core.telegram.org/bots/api#answershippingquery
-func (api *API) AnswerShippingQuery(args *AnswerShippingQueryArgs) (*bool, error) {
-	var success *bool
-	method := "answerShippingQuery"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
+func (p *AnswerShippingQueryArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	if err := p.validate(); err != nil {
 		return nil, err
 	}
-	return success, nil
+	return buildJSONRequestArgs(codec, p)
+}
+
+// https://core.telegram.org/bots/api#answershippingquery
+func (api *API) AnswerShippingQuery(args *AnswerShippingQueryArgs) (bool, error) {
+	return Call[bool](api, "answerShippingQuery", args)
 }
 
 type AnswerPreCheckoutQueryArgs struct {
@@ -1867,22 +1421,16 @@ type AnswerPreCheckoutQueryArgs struct {
 }
 
 func (p *AnswerPreCheckoutQueryArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *AnswerPreCheckoutQueryArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#answerprecheckoutquery
-func (api *API) AnswerPreCheckoutQuery(args *AnswerPreCheckoutQueryArgs) (*bool, error) {
-	var success *bool
-	method := "answerPreCheckoutQuery"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) AnswerPreCheckoutQuery(args *AnswerPreCheckoutQueryArgs) (bool, error) {
+	return Call[bool](api, "answerPreCheckoutQuery", args)
 }
 
 type SetPassportDataErrorsArgs struct {
@@ -1891,22 +1439,16 @@ type SetPassportDataErrorsArgs struct {
 }
 
 func (p *SetPassportDataErrorsArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SetPassportDataErrorsArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#setpassportdataerrors
-func (api *API) SetPassportDataErrors(args *SetPassportDataErrorsArgs) (*bool, error) {
-	var success *bool
-	method := "setPassportDataErrors"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &success); err != nil {
-		return nil, err
-	}
-	return success, nil
+func (api *API) SetPassportDataErrors(args *SetPassportDataErrorsArgs) (bool, error) {
+	return Call[bool](api, "setPassportDataErrors", args)
 }
 
 type SendGameArgs struct {
@@ -1918,71 +1460,59 @@ type SendGameArgs struct {
 }
 
 func (p *SendGameArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *SendGameArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	return buildJSONRequestArgs(codec, p)
 }
 
 // https://core.telegram.org/bots/api#sendgame
 func (api *API) SendGame(args *SendGameArgs) (*Message, error) {
-	var message *Message
-	method := "sendGame"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &message); err != nil {
-		return nil, err
-	}
-	return message, nil
+	return Call[*Message](api, "sendGame", args)
 }
 
 type SetGameScoreArgs struct {
-	UserID             int     `json:"user_id"`
-	Score              int     `json:"score"`
-	Force              bool    `json:"force,omitempty"`
-	DisableEditMessage bool    `json:"disable_edit_message,omitempty"`
-	ChatID             *ChatID `json:"chat_id,omitempty"`
-	MessageID          int     `json:"message_id,omitempty"`
-	InlineMessageID    string  `json:"inline_message_id,omitempty"`
+	UserID             int  `json:"user_id"`
+	Score              int  `json:"score"`
+	Force              bool `json:"force,omitempty"`
+	DisableEditMessage bool `json:"disable_edit_message,omitempty"`
+	*MessageRef
 }
 
 func (p *SetGameScoreArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
 }
 
-// https://core.telegram.org/bots/api#setgamescore
-func (api *API) SetGameScore(args *SetGameScoreArgs) (*OptionalMessage, error) {
-	method := "setGameScore"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
+func (p *SetGameScoreArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	if err := p.MessageRef.validate(); err != nil {
 		return nil, err
 	}
-	return buildOptionalMessage(response)
+	return buildJSONRequestArgs(codec, p)
+}
+
+// https://core.telegram.org/bots/api#setgamescore
+func (api *API) SetGameScore(args *SetGameScoreArgs) (*Message, error) {
+	return callEdit(api, "setGameScore", args)
 }
 
 type GetGameHighScoresArgs struct {
-	UserID          int     `json:"user_id"`
-	ChatID          *ChatID `json:"chat_id,omitempty"`
-	MessageID       int     `json:"message_id,omitempty"`
-	InlineMessageID string  `json:"inline_message_id,omitempty"`
+	UserID int `json:"user_id"`
+	*MessageRef
 }
 
 func (p *GetGameHighScoresArgs) GetRequestArgs() (*RequestArgs, error) {
-	return buildJSONRequestArgs(p)
+	return p.getRequestArgs(defaultCodec)
 }
 
-// https://core.telegram.org/bots/api#getgamehighscores
-func (api *API) GetGameHighScores(args *GetGameHighScoresArgs) (*[]*GameHighScore, error) {
-	var gameHighScore *[]*GameHighScore
-	method := "getGameHighScores"
-	timeout := Timeout * time.Second
-	response, err := api.execute(method, args, timeout)
-	if err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(*response, &gameHighScore); err != nil {
+func (p *GetGameHighScoresArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	if err := p.MessageRef.validate(); err != nil {
 		return nil, err
 	}
-	return gameHighScore, nil
+	return buildJSONRequestArgs(codec, p)
+}
+
+// https://core.telegram.org/bots/api#getgamehighscores
+func (api *API) GetGameHighScores(args *GetGameHighScoresArgs) ([]*GameHighScore, error) {
+	return Call[[]*GameHighScore](api, "getGameHighScores", args)
 }
@@ -82,8 +82,8 @@ func TestGetUpdates(t *testing.T) {
 	res, err := api.GetUpdates(args)
 	m.AssertExpectations(t)
 	assert.Nil(t, err)
-	assert.Equal(t, len(*res), 1)
-	for _, value := range *res {
+	assert.Equal(t, len(res), 1)
+	for _, value := range res {
 		assert.Equal(t, value.UpdateID, 123)
 		assert.Equal(t, value.Message.Text, "Hello, World!")
 	}
@@ -91,11 +91,14 @@ func TestGetUpdates(t *testing.T) {
 
 func TestSetWebhook(t *testing.T) {
 	m, api := setUpMock("setWebhook", commonTrueResponse)
-	args := &tg.SetWebhookArgs{URL: "https://example.com"}
+	args := &tg.SetWebhookArgs{URL: "https://example.com", SecretToken: "secret"}
+	request, err := args.GetRequestArgs()
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"url":"https://example.com","secret_token":"secret"}`, request.Body.String())
 	res, err := api.SetWebhook(args)
 	m.AssertExpectations(t)
 	assert.Nil(t, err)
-	assert.Equal(t, *res, true)
+	assert.Equal(t, res, true)
 }
 
 func TestDeleteWebhook(t *testing.T) {
@@ -104,7 +107,7 @@ func TestDeleteWebhook(t *testing.T) {
 	res, err := api.DeleteWebhook(args)
 	m.AssertExpectations(t)
 	assert.Nil(t, err)
-	assert.Equal(t, *res, true)
+	assert.Equal(t, res, true)
 }
 
 func TestGetWebhookInfo(t *testing.T) {
@@ -123,3 +126,111 @@ func TestGetWebhookInfo(t *testing.T) {
 	assert.Equal(t, res.URL, "https://example.com")
 	assert.Equal(t, res.PendingUpdateCount, 14)
 }
+
+func TestEditMessageText(t *testing.T) {
+	m, api := setUpMock("editMessageText", map[string]interface{}{
+		"ok":     true,
+		"result": commonMessage,
+	})
+	message := &tg.Message{MessageID: 123, Chat: &tg.Chat{ID: 123}}
+	args := &tg.EditMessageTextArgs{MessageRef: tg.NewMessageRef(message), Text: "Hello, World!"}
+	res, err := api.EditMessageText(args)
+	m.AssertExpectations(t)
+	assert.Nil(t, err)
+	assert.Equal(t, res.MessageID, 123)
+
+	m, api = setUpMock("editMessageText", commonTrueResponse)
+	query := &tg.CallbackQuery{InlineMessageID: "inline"}
+	args = &tg.EditMessageTextArgs{MessageRef: tg.NewCallbackQueryMessageRef(query), Text: "Hello, World!"}
+	res, err = api.EditMessageText(args)
+	m.AssertExpectations(t)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+
+	_, err = api.EditMessageText(&tg.EditMessageTextArgs{Text: "Hello, World!"})
+	assert.IsType(t, &tg.BuildRequestError{}, err)
+}
+
+func TestCallAPIError(t *testing.T) {
+	m, api := setUpMock("getMe", map[string]interface{}{
+		"ok":          false,
+		"error_code":  401,
+		"description": "Unauthorized",
+	})
+	res, err := tg.Call[*tg.User](api, "getMe", &tg.GetMeArgs{})
+	m.AssertExpectations(t)
+	assert.Nil(t, res)
+	assert.IsType(t, &tg.APIError{}, err)
+	assert.Equal(t, err.Error(), "Unauthorized")
+	assert.Equal(t, 401, err.(*tg.APIError).Code)
+}
+
+func TestAPIErrorParameters(t *testing.T) {
+	m, api := setUpMock("sendMessage", map[string]interface{}{
+		"ok":          false,
+		"error_code":  429,
+		"description": "Too Many Requests: retry after 5",
+		"parameters":  map[string]interface{}{"retry_after": 5},
+	})
+	_, err := api.SendMessage(&tg.SendMessageArgs{ChatID: &tg.ChatID{ID: 123}, Text: "Hello, World!"})
+	m.AssertExpectations(t)
+	apiErr, ok := err.(*tg.APIError)
+	assert.True(t, ok)
+	assert.Equal(t, 429, apiErr.Code)
+	assert.Equal(t, 5, apiErr.Parameters.RetryAfter)
+}
+
+func TestRawCall(t *testing.T) {
+	m, api := setUpMock("getMyCommands", map[string]interface{}{
+		"ok": true,
+		"result": []interface{}{
+			map[string]interface{}{"command": "start", "description": "Start the bot"},
+		},
+	})
+	var commands []struct {
+		Command     string `json:"command"`
+		Description string `json:"description"`
+	}
+	err := api.Call("getMyCommands", map[string]interface{}{"scope": map[string]string{"type": "default"}}, &commands)
+	m.AssertExpectations(t)
+	assert.Nil(t, err)
+	assert.Equal(t, len(commands), 1)
+	assert.Equal(t, commands[0].Command, "start")
+
+	err = api.CallMultipart("setChatPhoto", nil, []*tg.InputFile{{}}, nil)
+	assert.IsType(t, &tg.BuildRequestError{}, err)
+}
+
+type countingCodec struct {
+	tg.JSONCodec
+	marshal   int
+	unmarshal int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshal++
+	return c.JSONCodec.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshal++
+	return c.JSONCodec.Unmarshal(data, v)
+}
+
+func TestCodec(t *testing.T) {
+	m, api := setUpMock("editMessageText", map[string]interface{}{
+		"ok":     true,
+		"result": commonMessage,
+	})
+	codec := &countingCodec{}
+	api.Codec = codec
+	res, err := api.EditMessageText(&tg.EditMessageTextArgs{
+		MessageRef: tg.NewInlineMessageRef("abc"),
+		Text:       "Hello, World!",
+	})
+	m.AssertExpectations(t)
+	assert.Nil(t, err)
+	assert.Equal(t, 123, res.MessageID)
+	assert.Equal(t, 1, codec.marshal)
+	assert.Equal(t, 2, codec.unmarshal)
+}
@@ -0,0 +1,348 @@
+package tg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	BroadcastRate        = 25 // messages per second, Telegram allows about 30
+	BroadcastConcurrency = 8
+)
+
+type BroadcastStatus string
+
+const (
+	BroadcastStatusSent         BroadcastStatus = "sent"
+	BroadcastStatusMigrated     BroadcastStatus = "migrated"
+	BroadcastStatusBlocked      BroadcastStatus = "blocked"
+	BroadcastStatusDeactivated  BroadcastStatus = "deactivated"
+	BroadcastStatusChatNotFound BroadcastStatus = "chat_not_found"
+	BroadcastStatusFailed       BroadcastStatus = "failed"
+)
+
+// ClassifyBroadcastError returns the status of a message sent with the error.
+// Failed means that the error is not permanent and sending can be retried.
+func ClassifyBroadcastError(err error) BroadcastStatus {
+	if err == nil {
+		return BroadcastStatusSent
+	}
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		return BroadcastStatusFailed
+	}
+	description := strings.ToLower(apiErr.Error())
+	switch {
+	case apiErr.Parameters != nil && apiErr.Parameters.MigrateToChatID != 0:
+		return BroadcastStatusMigrated
+	case strings.Contains(description, "deactivated"):
+		return BroadcastStatusDeactivated
+	case strings.Contains(description, "chat not found"):
+		return BroadcastStatusChatNotFound
+	case strings.Contains(description, "blocked"), strings.Contains(description, "kicked"):
+		return BroadcastStatusBlocked
+	}
+	return BroadcastStatusFailed
+}
+
+// BroadcastRecipients is a source of chat IDs.
+type BroadcastRecipients interface {
+	// Next returns false when there are no more recipients.
+	Next() (int, bool, error)
+}
+
+type SliceRecipients struct {
+	ChatIDs []int
+	pos     int
+}
+
+func (r *SliceRecipients) Next() (int, bool, error) {
+	if r.pos >= len(r.ChatIDs) {
+		return 0, false, nil
+	}
+	r.pos++
+	return r.ChatIDs[r.pos-1], true, nil
+}
+
+// BroadcastStore persists statuses of sent messages so an interrupted broadcast can be resumed.
+type BroadcastStore interface {
+	Load(broadcastID string) (map[int]BroadcastStatus, error)
+	Save(broadcastID string, chatID int, status BroadcastStatus) error
+}
+
+type MemoryBroadcastStore struct {
+	mu         sync.Mutex
+	broadcasts map[string]map[int]BroadcastStatus
+}
+
+func (s *MemoryBroadcastStore) Load(broadcastID string) (map[int]BroadcastStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[int]BroadcastStatus)
+	for chatID, status := range s.broadcasts[broadcastID] {
+		result[chatID] = status
+	}
+	return result, nil
+}
+
+func (s *MemoryBroadcastStore) Save(broadcastID string, chatID int, status BroadcastStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.broadcasts == nil {
+		s.broadcasts = make(map[string]map[int]BroadcastStatus)
+	}
+	if s.broadcasts[broadcastID] == nil {
+		s.broadcasts[broadcastID] = make(map[int]BroadcastStatus)
+	}
+	s.broadcasts[broadcastID][chatID] = status
+	return nil
+}
+
+// FileBroadcastStore appends statuses to the <Dir>/<broadcast ID>.broadcast file.
+type FileBroadcastStore struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+func (s *FileBroadcastStore) fileName(broadcastID string) string {
+	return filepath.Join(s.Dir, broadcastID+".broadcast")
+}
+
+func (s *FileBroadcastStore) Load(broadcastID string) (map[int]BroadcastStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[int]BroadcastStatus)
+	file, err := os.Open(s.fileName(broadcastID))
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var chatID int
+		var status BroadcastStatus
+		// a line can be truncated by a crash
+		if _, err = fmt.Sscan(scanner.Text(), &chatID, &status); err == nil {
+			result[chatID] = status
+		}
+	}
+	return result, scanner.Err()
+}
+
+func (s *FileBroadcastStore) Save(broadcastID string, chatID int, status BroadcastStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.fileName(broadcastID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(file, "%d %s\n", chatID, status); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+type BroadcastStats struct {
+	Sent         int
+	Migrated     int
+	Blocked      int
+	Deactivated  int
+	ChatNotFound int
+	Failed       int
+	Skipped      int // already sent before the broadcast was resumed
+}
+
+func (s *BroadcastStats) add(status BroadcastStatus) {
+	switch status {
+	case BroadcastStatusSent:
+		s.Sent++
+	case BroadcastStatusMigrated:
+		s.Migrated++
+	case BroadcastStatusBlocked:
+		s.Blocked++
+	case BroadcastStatusDeactivated:
+		s.Deactivated++
+	case BroadcastStatusChatNotFound:
+		s.ChatNotFound++
+	default:
+		s.Failed++
+	}
+}
+
+// Broadcast sends the Message to every recipient under the rate limit.
+// Statuses except failed are saved to the Store, so running a broadcast with
+// the same ID again skips the recipients which already have a final status.
+// Messages to migrated chats are resent to the new chat and messages limited
+// by flood control are retried after the requested delay.
+type Broadcast struct {
+	ID          string
+	API         *API
+	Message     *SendMessageArgs // ChatID is set for every recipient
+	Recipients  BroadcastRecipients
+	Store       BroadcastStore             // Optional
+	Rate        int                        // Optional, BroadcastRate is used by default
+	Concurrency int                        // Optional, BroadcastConcurrency is used by default
+	Progress    func(stats BroadcastStats) // Optional, called after every message, never concurrently
+	mu          sync.Mutex
+	stats       BroadcastStats
+	err         error
+	progressMu  sync.Mutex
+}
+
+func (b *Broadcast) getRate() int {
+	if b.Rate > 0 {
+		return b.Rate
+	}
+	return BroadcastRate
+}
+
+func (b *Broadcast) getConcurrency() int {
+	if b.Concurrency > 0 {
+		return b.Concurrency
+	}
+	return BroadcastConcurrency
+}
+
+// Stats returns the live statistics of the broadcast.
+func (b *Broadcast) Stats() BroadcastStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+func (b *Broadcast) getErr() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+func (b *Broadcast) record(chatID int, status BroadcastStatus, skipped bool) {
+	var err error
+	if !skipped && status != BroadcastStatusFailed && b.Store != nil {
+		err = b.Store.Save(b.ID, chatID, status)
+	}
+	b.mu.Lock()
+	if skipped {
+		b.stats.Skipped++
+	} else {
+		b.stats.add(status)
+	}
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+	stats := b.stats
+	b.mu.Unlock()
+	if b.Progress != nil {
+		b.progressMu.Lock()
+		b.Progress(stats)
+		b.progressMu.Unlock()
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (b *Broadcast) send(ctx context.Context, chatID int) BroadcastStatus {
+	migrated := false
+	for {
+		args := *b.Message
+		args.ChatID = &ChatID{ID: chatID}
+		_, err := b.API.SendMessage(&args)
+		if apiErr, ok := err.(*APIError); ok && apiErr.Parameters != nil {
+			if apiErr.Parameters.RetryAfter > 0 {
+				if sleepContext(ctx, time.Duration(apiErr.Parameters.RetryAfter)*time.Second) != nil {
+					return BroadcastStatusFailed
+				}
+				b.API.observeRetry("sendMessage")
+				continue
+			}
+			if apiErr.Parameters.MigrateToChatID != 0 && !migrated {
+				chatID = apiErr.Parameters.MigrateToChatID
+				migrated = true
+				b.API.observeRetry("sendMessage")
+				continue
+			}
+		}
+		status := ClassifyBroadcastError(err)
+		if migrated && status == BroadcastStatusSent {
+			status = BroadcastStatusMigrated
+		}
+		return status
+	}
+}
+
+// Run sends the broadcast and returns when all recipients are processed or ctx is done.
+func (b *Broadcast) Run(ctx context.Context) error {
+	done := make(map[int]BroadcastStatus)
+	if b.Store != nil {
+		var err error
+		if done, err = b.Store.Load(b.ID); err != nil {
+			return err
+		}
+	}
+	ticker := time.NewTicker(time.Second / time.Duration(b.getRate()))
+	defer ticker.Stop()
+
+	chatIDs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < b.getConcurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chatID := range chatIDs {
+				b.record(chatID, b.send(ctx, chatID), false)
+			}
+		}()
+	}
+	err := b.produce(ctx, ticker, done, chatIDs)
+	close(chatIDs)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	return b.getErr()
+}
+
+func (b *Broadcast) produce(ctx context.Context, ticker *time.Ticker, done map[int]BroadcastStatus, chatIDs chan<- int) error {
+	for {
+		chatID, ok, err := b.Recipients.Next()
+		if err != nil || !ok {
+			return err
+		}
+		if _, ok = done[chatID]; ok {
+			b.record(chatID, done[chatID], true)
+			continue
+		}
+		if err = b.getErr(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case chatIDs <- chatID:
+		}
+	}
+}
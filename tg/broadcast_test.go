@@ -0,0 +1,107 @@
+package tg_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"sync"
+	"testing"
+	"time"
+)
+
+type broadcastClient struct {
+	mu        sync.Mutex
+	responses map[int]map[string]interface{}
+	sent      []int
+}
+
+func (c *broadcastClient) Do(url string, args *tg.RequestArgs, timeout time.Duration) ([]byte, error) {
+	var params struct {
+		ChatID int `json:"chat_id"`
+	}
+	if err := json.Unmarshal(args.Body.Bytes(), &params); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, params.ChatID)
+	if response, ok := c.responses[params.ChatID]; ok {
+		return json.Marshal(response)
+	}
+	return json.Marshal(map[string]interface{}{"ok": true, "result": commonMessage})
+}
+
+func errorResponse(code int, description string, parameters map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"ok":          false,
+		"error_code":  code,
+		"description": description,
+		"parameters":  parameters,
+	}
+}
+
+func TestClassifyBroadcastError(t *testing.T) {
+	assert.Equal(t, tg.BroadcastStatusSent, tg.ClassifyBroadcastError(nil))
+	assert.Equal(t, tg.BroadcastStatusFailed, tg.ClassifyBroadcastError(errors.New("timeout")))
+	assert.Equal(t, tg.BroadcastStatusBlocked, tg.ClassifyBroadcastError(tg.NewAPIError("Forbidden: bot was blocked by the user")))
+	assert.Equal(t, tg.BroadcastStatusDeactivated, tg.ClassifyBroadcastError(tg.NewAPIError("Forbidden: user is deactivated")))
+	assert.Equal(t, tg.BroadcastStatusChatNotFound, tg.ClassifyBroadcastError(tg.NewAPIError("Bad Request: chat not found")))
+}
+
+func TestBroadcast(t *testing.T) {
+	client := &broadcastClient{responses: map[int]map[string]interface{}{
+		2: errorResponse(403, "Forbidden: bot was blocked by the user", nil),
+		3: errorResponse(403, "Forbidden: user is deactivated", nil),
+		4: errorResponse(400, "Bad Request: chat not found", nil),
+		5: errorResponse(400, "Bad Request: group chat was upgraded to a supergroup chat", map[string]interface{}{"migrate_to_chat_id": -100}),
+		6: errorResponse(500, "Internal Server Error", nil),
+	}}
+	api := &tg.API{Token: "TOKEN", Client: client}
+	store := &tg.MemoryBroadcastStore{}
+	newBroadcast := func() *tg.Broadcast {
+		return &tg.Broadcast{
+			ID:         "news",
+			API:        api,
+			Message:    &tg.SendMessageArgs{Text: "Hello, World!"},
+			Recipients: &tg.SliceRecipients{ChatIDs: []int{1, 2, 3, 4, 5, 6}},
+			Store:      store,
+			Rate:       1000,
+		}
+	}
+
+	broadcast := newBroadcast()
+	var progress int
+	broadcast.Progress = func(stats tg.BroadcastStats) { progress++ }
+	assert.Nil(t, broadcast.Run(context.Background()))
+	assert.Equal(t, tg.BroadcastStats{
+		Sent:         1,
+		Migrated:     1,
+		Blocked:      1,
+		Deactivated:  1,
+		ChatNotFound: 1,
+		Failed:       1,
+	}, broadcast.Stats())
+	assert.Equal(t, 6, progress)
+	assert.Contains(t, client.sent, -100)
+
+	client.sent = nil
+	delete(client.responses, 6)
+	broadcast = newBroadcast()
+	assert.Nil(t, broadcast.Run(context.Background()))
+	assert.Equal(t, []int{6}, client.sent)
+	assert.Equal(t, tg.BroadcastStats{Sent: 1, Skipped: 5}, broadcast.Stats())
+}
+
+func TestFileBroadcastStore(t *testing.T) {
+	store := &tg.FileBroadcastStore{Dir: t.TempDir()}
+	statuses, err := store.Load("news")
+	assert.Nil(t, err)
+	assert.Len(t, statuses, 0)
+	assert.Nil(t, store.Save("news", 1, tg.BroadcastStatusSent))
+	assert.Nil(t, store.Save("news", -2, tg.BroadcastStatusBlocked))
+	statuses, err = store.Load("news")
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]tg.BroadcastStatus{1: tg.BroadcastStatusSent, -2: tg.BroadcastStatusBlocked}, statuses)
+}
@@ -0,0 +1,220 @@
+package tg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+const (
+	CallbackDataMaxSize = 64
+	CallbackStoreSize   = 10000
+	callbackSignSize    = 8 // bytes of HMAC, 11 characters in base64
+	callbackInline      = '['
+	callbackStored      = '#'
+)
+
+var ErrCallbackSignature = errors.New("callback data signature is invalid")
+
+// CallbackStore keeps payloads which do not fit into the callback data.
+// A store can remove old payloads, data of their buttons is expired then.
+type CallbackStore interface {
+	Put(key string, payload string) error
+	Get(key string) (string, bool, error)
+}
+
+// MemoryCallbackStore keeps at most Size payloads, the oldest payloads are
+// removed first, so their buttons are expired.
+type MemoryCallbackStore struct {
+	Size     int // Optional, CallbackStoreSize by default
+	mu       sync.Mutex
+	payloads map[string]string
+	keys     []string // in the order of adding
+}
+
+func (s *MemoryCallbackStore) getSize() int {
+	if s.Size > 0 {
+		return s.Size
+	}
+	return CallbackStoreSize
+}
+
+func (s *MemoryCallbackStore) Put(key string, payload string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.payloads == nil {
+		s.payloads = make(map[string]string)
+	}
+	if _, ok := s.payloads[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+	s.payloads[key] = payload
+	for len(s.keys) > s.getSize() {
+		delete(s.payloads, s.keys[0])
+		s.keys = s.keys[1:]
+	}
+	return nil
+}
+
+func (s *MemoryCallbackStore) Get(key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	payload, ok := s.payloads[key]
+	return payload, ok, nil
+}
+
+// CallbackCodec encodes structs into the callback data of inline keyboard buttons.
+// The data is a JSON array of the name and the exported fields of the struct in
+// their order, trailing zero fields are omitted. With the Secret the data is
+// signed, so data changed by a client is rejected. Data longer than 64 bytes is
+// kept in the Store and the button gets a short key of it, the key is a hash
+// of the data, so the same data is stored once.
+type CallbackCodec struct {
+	Secret []byte        // Optional
+	Store  CallbackStore // Optional, without it long data can not be encoded
+}
+
+// CallbackData is the decoded callback data, see CallbackCodec.
+type CallbackData struct {
+	Name   string
+	values []json.RawMessage
+}
+
+func (c *CallbackCodec) sign(payload string) string {
+	if len(c.Secret) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, c.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSignSize])
+}
+
+func structValue(v interface{}) (reflect.Value, error) {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("unsupported callback data type %T", v)
+	}
+	return value, nil
+}
+
+func callbackFields(t reflect.Type) []int {
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath == "" && field.Tag.Get("json") != "-" {
+			fields = append(fields, i)
+		}
+	}
+	return fields
+}
+
+// Encode returns the callback data of v, a struct or a pointer to a struct.
+// The name identifies the kind of the data, see CallbackData.
+func (c *CallbackCodec) Encode(name string, v interface{}) (string, error) {
+	value, err := structValue(v)
+	if err != nil {
+		return "", err
+	}
+	fields := callbackFields(value.Type())
+	last := len(fields)
+	for last > 0 && value.Field(fields[last-1]).IsZero() {
+		last--
+	}
+	values := []interface{}{name}
+	for _, i := range fields[:last] {
+		values = append(values, value.Field(i).Interface())
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	payload := string(data)
+	if result := c.sign(payload) + payload; len(result) <= CallbackDataMaxSize {
+		return result, nil
+	}
+	if c.Store == nil {
+		return "", fmt.Errorf("callback data is %d bytes long, the maximum is %d", len(payload), CallbackDataMaxSize)
+	}
+	key := sha256.Sum256(data)
+	stored := string(callbackStored) + base64.RawURLEncoding.EncodeToString(key[:12])
+	if err = c.Store.Put(stored[1:], payload); err != nil {
+		return "", err
+	}
+	return c.sign(stored) + stored, nil
+}
+
+// Parse verifies the data and loads it from the Store if needed.
+func (c *CallbackCodec) Parse(data string) (*CallbackData, error) {
+	signSize := 0
+	if len(c.Secret) > 0 {
+		signSize = base64.RawURLEncoding.EncodedLen(callbackSignSize)
+	}
+	if len(data) <= signSize {
+		return nil, errors.New("callback data is too short")
+	}
+	signature, payload := data[:signSize], data[signSize:]
+	if !hmac.Equal([]byte(signature), []byte(c.sign(payload))) {
+		return nil, ErrCallbackSignature
+	}
+	if payload[0] == callbackStored {
+		if c.Store == nil {
+			return nil, errors.New("callback data store is not set")
+		}
+		stored, ok, err := c.Store.Get(payload[1:])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("callback data is expired")
+		}
+		payload = stored
+	}
+	var values []json.RawMessage
+	if payload == "" || payload[0] != callbackInline || json.Unmarshal([]byte(payload), &values) != nil || len(values) == 0 {
+		return nil, errors.New("callback data is malformed")
+	}
+	result := &CallbackData{values: values[1:]}
+	if err := json.Unmarshal(values[0], &result.Name); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Decode decodes the fields into v, a pointer to the struct used for Encode.
+func (d *CallbackData) Decode(v interface{}) error {
+	if value := reflect.ValueOf(v); value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("unsupported callback data type %T", v)
+	}
+	value, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	fields := callbackFields(value.Type())
+	if len(d.values) > len(fields) {
+		return errors.New("callback data has too many fields")
+	}
+	for i, raw := range d.values {
+		if err = json.Unmarshal(raw, value.Field(fields[i]).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeQuery parses the data of the query and decodes it into v.
+// It returns the name of the data, so handlers can check it.
+func (c *CallbackCodec) DecodeQuery(query *CallbackQuery, v interface{}) (string, error) {
+	data, err := c.Parse(query.Data)
+	if err != nil {
+		return "", err
+	}
+	return data.Name, data.Decode(v)
+}
@@ -0,0 +1,84 @@
+package tg_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"strings"
+	"testing"
+)
+
+type buyCallback struct {
+	ItemID   int
+	Quantity int
+	Note     string
+}
+
+func TestCallbackCodec(t *testing.T) {
+	codec := &tg.CallbackCodec{}
+	data, err := codec.Encode("buy", buyCallback{ItemID: 42, Quantity: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, `["buy",42,3]`, data)
+
+	var decoded buyCallback
+	name, err := codec.DecodeQuery(&tg.CallbackQuery{Data: data}, &decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, "buy", name)
+	assert.Equal(t, buyCallback{ItemID: 42, Quantity: 3}, decoded)
+
+	_, err = codec.Encode("buy", &buyCallback{Note: strings.Repeat("x", 64)})
+	assert.Error(t, err)
+}
+
+func TestSignedCallbackCodec(t *testing.T) {
+	codec := &tg.CallbackCodec{Secret: []byte("secret"), Store: &tg.MemoryCallbackStore{}}
+	data, err := codec.Encode("buy", &buyCallback{ItemID: 42})
+	assert.Nil(t, err)
+	assert.True(t, len(data) <= tg.CallbackDataMaxSize)
+	parsed, err := codec.Parse(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "buy", parsed.Name)
+
+	forged := strings.Replace(data, "42", "43", 1)
+	_, err = codec.Parse(forged)
+	assert.Equal(t, tg.ErrCallbackSignature, err)
+
+	long := buyCallback{ItemID: 42, Quantity: 1, Note: strings.Repeat("note ", 30)}
+	data, err = codec.Encode("buy", long)
+	assert.Nil(t, err)
+	assert.True(t, len(data) <= tg.CallbackDataMaxSize)
+	var decoded buyCallback
+	name, err := codec.DecodeQuery(&tg.CallbackQuery{Data: data}, &decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, "buy", name)
+	assert.Equal(t, long, decoded)
+
+	_, err = (&tg.CallbackCodec{Secret: []byte("other"), Store: codec.Store}).Parse(data)
+	assert.Equal(t, tg.ErrCallbackSignature, err)
+}
+
+func TestMemoryCallbackStore(t *testing.T) {
+	store := &tg.MemoryCallbackStore{Size: 2}
+	codec := &tg.CallbackCodec{Store: store}
+	long := func(i int) buyCallback {
+		return buyCallback{ItemID: i, Note: strings.Repeat("note ", 30)}
+	}
+	first, err := codec.Encode("buy", long(1))
+	assert.Nil(t, err)
+	// the same data is stored once
+	again, err := codec.Encode("buy", long(1))
+	assert.Nil(t, err)
+	assert.Equal(t, first, again)
+
+	second, err := codec.Encode("buy", long(2))
+	assert.Nil(t, err)
+	_, err = codec.Parse(first)
+	assert.Nil(t, err)
+	_, err = codec.Encode("buy", long(3))
+	assert.Nil(t, err)
+
+	// the oldest data is removed
+	_, err = codec.Parse(first)
+	assert.EqualError(t, err, "callback data is expired")
+	_, err = codec.Parse(second)
+	assert.Nil(t, err)
+}
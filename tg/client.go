@@ -0,0 +1,244 @@
+package tg
+
+//go:generate go run ../internal/tggen -spec botapi.json -api api.go -types types.go
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const Timeout = 15
+
+type HttpClient interface {
+	Do(url string, args *RequestArgs, timeout time.Duration) ([]byte, error)
+}
+
+type MethodArgs interface {
+	GetRequestArgs() (*RequestArgs, error)
+}
+
+type RequestArgs struct {
+	Body    *bytes.Buffer
+	Headers map[string]string
+}
+
+type DefaultHttpClient struct {
+	Transport http.RoundTripper // Optional, can be shared by several clients
+}
+
+func (c *DefaultHttpClient) Do(url string, args *RequestArgs, timeout time.Duration) ([]byte, error) {
+	client := &http.Client{Timeout: timeout, Transport: c.Transport}
+	request, _ := http.NewRequest("POST", url, args.Body)
+	for key, value := range args.Headers {
+		request.Header.Set(key, value)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	size := 512
+	if response.ContentLength > 0 {
+		size = int(response.ContentLength)
+	}
+	result := bytes.NewBuffer(make([]byte, 0, size))
+	if _, err = result.ReadFrom(response.Body); err != nil {
+		return nil, err
+	}
+	if err = response.Body.Close(); err != nil {
+		return nil, err
+	}
+	return result.Bytes(), nil
+}
+
+// https://core.telegram.org/bots/api, see APIVersion for the supported version
+type API struct {
+	Token        string
+	Client       HttpClient
+	Codec        Codec         // Optional, JSONCodec is used by default
+	Metrics      Metrics       // Optional
+	Interceptors []Interceptor // Optional, the first one is the outermost
+}
+
+func (api *API) codec() Codec {
+	if api.Codec != nil {
+		return api.Codec
+	}
+	return defaultCodec
+}
+
+func (api *API) buildRequestArgs(args MethodArgs) (*RequestArgs, error) {
+	if p, ok := args.(codecArgs); ok {
+		return p.getRequestArgs(api.codec())
+	}
+	return args.GetRequestArgs()
+}
+
+func (api *API) buildURL(method string) string {
+	return fmt.Sprintf("https://api.telegram.org/bot%s/%s", api.Token, method)
+}
+
+// Redact replaces the token in s, e.g. in an error of the http client which includes the URL.
+func (api *API) Redact(s string) string {
+	if api.Token == "" {
+		return s
+	}
+	s = strings.ReplaceAll(s, api.Token, "<token>")
+	return strings.ReplaceAll(s, url.PathEscape(api.Token), "<token>")
+}
+
+func (api *API) sendRequest(url string, args *RequestArgs, timeout time.Duration) ([]byte, error) {
+	if api.Client != nil {
+		return api.Client.Do(url, args, timeout)
+	}
+	client := new(DefaultHttpClient)
+	return client.Do(url, args, timeout)
+
+}
+
+type timeoutArgs interface {
+	getTimeout() time.Duration
+}
+
+func getTimeout(args MethodArgs) time.Duration {
+	if p, ok := args.(timeoutArgs); ok {
+		return p.getTimeout()
+	}
+	return Timeout * time.Second
+}
+
+type apiResponse interface {
+	checkIfSuccess() error
+	reset()
+}
+
+// response is decoded in a single pass together with the method result.
+type response[T any] struct {
+	Ok          bool                `json:"ok"`
+	Description string              `json:"description"`
+	ErrorCode   int                 `json:"error_code"`
+	Parameters  *ResponseParameters `json:"parameters"`
+	Result      T                   `json:"result"`
+}
+
+func (r *response[T]) checkIfSuccess() error {
+	if !r.Ok {
+		return &APIError{
+			message:    r.Description,
+			Code:       r.ErrorCode,
+			Parameters: r.Parameters,
+		}
+	}
+	return nil
+}
+
+// reset clears the envelope, so the response can be decoded again when a call is repeated.
+func (r *response[T]) reset() {
+	r.Ok = false
+	r.Description = ""
+	r.ErrorCode = 0
+	r.Parameters = nil
+}
+
+func (api *API) parseResponseBody(body []byte, response apiResponse) error {
+	return api.codec().Unmarshal(body, response)
+}
+
+func (api *API) execute(method string, args MethodArgs, response apiResponse) (err error) {
+	stats := &CallStats{}
+	inv := &Invocation{Method: method, Args: args, api: api}
+	if api.Metrics != nil {
+		start := time.Now()
+		defer func() {
+			stats.Duration = time.Since(start)
+			stats.ResponseSize = len(inv.Response)
+			stats.Err = err
+			api.Metrics.ObserveCall(method, stats)
+		}()
+	}
+	if inv.Request, err = api.buildRequestArgs(args); err != nil {
+		return NewBuildRequestError(api.Redact(err.Error()))
+	}
+	if inv.Request.Body != nil {
+		stats.RequestSize = inv.Request.Body.Len()
+	}
+	err = api.intercept(inv, func(inv *Invocation) error {
+		body, err := api.sendRequest(api.buildURL(inv.Method), inv.Request, getTimeout(inv.Args))
+		if err != nil {
+			return NewSendRequestError(api.Redact(err.Error()))
+		}
+		inv.Response = body
+		return api.decodeResponse(inv, response)
+	})
+	if err == nil && !inv.decoded {
+		// an interceptor provided the response without sending the request
+		err = api.decodeResponse(inv, response)
+	}
+	return err
+}
+
+func (api *API) decodeResponse(inv *Invocation, response apiResponse) error {
+	inv.decoded = true
+	response.reset()
+	if err := api.parseResponseBody(inv.Response, response); err != nil {
+		return NewParseResponseBodyError(err.Error())
+	}
+	return response.checkIfSuccess()
+}
+
+// Call executes the method and decodes its result into T.
+func Call[T any](api *API, method string, args MethodArgs) (T, error) {
+	var result response[T]
+	if err := api.execute(method, args, &result); err != nil {
+		var zero T
+		return zero, err
+	}
+	return result.Result, nil
+}
+
+type rawArgs struct {
+	params interface{}
+	files  []*InputFile
+}
+
+func (p *rawArgs) GetRequestArgs() (*RequestArgs, error) {
+	return p.getRequestArgs(defaultCodec)
+}
+
+func (p *rawArgs) getRequestArgs(codec Codec) (*RequestArgs, error) {
+	if len(p.files) > 0 {
+		args, err := marshallParams(codec, p.params)
+		if err != nil {
+			return nil, err
+		}
+		return buildMultipartRequestArgs(args, p.files)
+	}
+	if p.params == nil {
+		return buildJSONRequestArgs(codec, struct{}{})
+	}
+	return buildJSONRequestArgs(codec, p.params)
+}
+
+// Call executes a method which is not covered by the library yet.
+// Params are sent as JSON and the method result is decoded into result.
+func (api *API) Call(method string, params interface{}, result interface{}) error {
+	return api.execute(method, &rawArgs{params: params}, &response[interface{}]{Result: result})
+}
+
+// CallMultipart works like Call but uploads files. Params must be a map or
+// a pointer to a struct with json tags, the values are sent as form fields.
+func (api *API) CallMultipart(method string, params interface{}, files []*InputFile, result interface{}) error {
+	var uploadFiles []*InputFile
+	for _, file := range files {
+		if file.isAllSet() {
+			uploadFiles = append(uploadFiles, file)
+		}
+	}
+	if len(uploadFiles) == 0 {
+		return NewBuildRequestError("no files to upload")
+	}
+	return api.execute(method, &rawArgs{params: params, files: uploadFiles}, &response[interface{}]{Result: result})
+}
@@ -0,0 +1,88 @@
+package tg_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/websuslik/unibot/tg"
+	"testing"
+	"time"
+)
+
+type staticHttpClient struct {
+	body []byte
+}
+
+func (c *staticHttpClient) Do(url string, args *tg.RequestArgs, timeout time.Duration) ([]byte, error) {
+	return c.body, nil
+}
+
+func updatesBody(count int) []byte {
+	updates := make([]interface{}, count)
+	for i := range updates {
+		updates[i] = map[string]interface{}{
+			"update_id": i + 1,
+			"message":   commonMessage,
+		}
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"ok":     true,
+		"result": updates,
+	})
+	return body
+}
+
+// getUpdatesTwoPass is how API.GetUpdates decoded responses before: the
+// envelope into a map of raw messages and then the result once again.
+func getUpdatesTwoPass(client tg.HttpClient, args *tg.GetUpdatesArgs) ([]*tg.Update, error) {
+	request, err := args.GetRequestArgs()
+	if err != nil {
+		return nil, err
+	}
+	body, err := client.Do("https://api.telegram.org/botTOKEN/getUpdates", request, tg.Timeout*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]*json.RawMessage
+	if err = json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	var ok bool
+	if err = json.Unmarshal(*data["ok"], &ok); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("not ok")
+	}
+	var result []*tg.Update
+	if err = json.Unmarshal(*data["result"], &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// BenchmarkGetUpdatesTwoPass is the baseline of BenchmarkGetUpdates.
+func BenchmarkGetUpdatesTwoPass(b *testing.B) {
+	body := updatesBody(100)
+	client := &staticHttpClient{body: body}
+	args := &tg.GetUpdatesArgs{}
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := getUpdatesTwoPass(client, args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetUpdates(b *testing.B) {
+	body := updatesBody(100)
+	api := &tg.API{Token: "TOKEN", Client: &staticHttpClient{body: body}}
+	args := &tg.GetUpdatesArgs{}
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := api.GetUpdates(args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package tg
+
+import "encoding/json"
+
+// Codec marshals method params and unmarshals responses. Values use the
+// encoding/json struct tags, so the codec must respect them.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is the default codec based on encoding/json.
+type JSONCodec struct {
+}
+
+func (c JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (c JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+var defaultCodec Codec = JSONCodec{}
+
+// codecArgs are method args which can be marshalled with the codec of API.
+type codecArgs interface {
+	getRequestArgs(codec Codec) (*RequestArgs, error)
+}
@@ -0,0 +1,306 @@
+package tg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// https://core.telegram.org/bots/payments#supported-currencies
+type Currency struct {
+	Code      string
+	Symbol    string
+	Exp       int // number of digits past the decimal point
+	MinAmount int // in minor units, 0 if unknown
+	MaxAmount int // in minor units, 0 if unknown
+}
+
+var (
+	currenciesMu sync.RWMutex
+	// Amount limits are known for USD only, they depend on exchange rates
+	// and can be loaded from Telegram with LoadCurrencies.
+	currencies = map[string]*Currency{
+		"AED": {Code: "AED", Symbol: "AED", Exp: 2},
+		"AFN": {Code: "AFN", Symbol: "AFN", Exp: 2},
+		"ALL": {Code: "ALL", Symbol: "ALL", Exp: 2},
+		"AMD": {Code: "AMD", Symbol: "AMD", Exp: 2},
+		"ARS": {Code: "ARS", Symbol: "ARS", Exp: 2},
+		"AUD": {Code: "AUD", Symbol: "AU$", Exp: 2},
+		"AZN": {Code: "AZN", Symbol: "AZN", Exp: 2},
+		"BAM": {Code: "BAM", Symbol: "BAM", Exp: 2},
+		"BDT": {Code: "BDT", Symbol: "BDT", Exp: 2},
+		"BGN": {Code: "BGN", Symbol: "BGN", Exp: 2},
+		"BND": {Code: "BND", Symbol: "BND", Exp: 2},
+		"BOB": {Code: "BOB", Symbol: "BOB", Exp: 2},
+		"BRL": {Code: "BRL", Symbol: "R$", Exp: 2},
+		"BYN": {Code: "BYN", Symbol: "BYN", Exp: 2},
+		"CAD": {Code: "CAD", Symbol: "CA$", Exp: 2},
+		"CHF": {Code: "CHF", Symbol: "CHF", Exp: 2},
+		"CLP": {Code: "CLP", Symbol: "CLP", Exp: 0},
+		"CNY": {Code: "CNY", Symbol: "CN¥", Exp: 2},
+		"COP": {Code: "COP", Symbol: "COP", Exp: 2},
+		"CRC": {Code: "CRC", Symbol: "CRC", Exp: 2},
+		"CZK": {Code: "CZK", Symbol: "CZK", Exp: 2},
+		"DKK": {Code: "DKK", Symbol: "DKK", Exp: 2},
+		"DOP": {Code: "DOP", Symbol: "DOP", Exp: 2},
+		"DZD": {Code: "DZD", Symbol: "DZD", Exp: 2},
+		"EGP": {Code: "EGP", Symbol: "EGP", Exp: 2},
+		"ETB": {Code: "ETB", Symbol: "ETB", Exp: 2},
+		"EUR": {Code: "EUR", Symbol: "€", Exp: 2},
+		"GBP": {Code: "GBP", Symbol: "£", Exp: 2},
+		"GEL": {Code: "GEL", Symbol: "GEL", Exp: 2},
+		"GTQ": {Code: "GTQ", Symbol: "GTQ", Exp: 2},
+		"HKD": {Code: "HKD", Symbol: "HK$", Exp: 2},
+		"HNL": {Code: "HNL", Symbol: "HNL", Exp: 2},
+		"HRK": {Code: "HRK", Symbol: "HRK", Exp: 2},
+		"HUF": {Code: "HUF", Symbol: "HUF", Exp: 2},
+		"IDR": {Code: "IDR", Symbol: "IDR", Exp: 2},
+		"ILS": {Code: "ILS", Symbol: "₪", Exp: 2},
+		"INR": {Code: "INR", Symbol: "₹", Exp: 2},
+		"ISK": {Code: "ISK", Symbol: "ISK", Exp: 0},
+		"JMD": {Code: "JMD", Symbol: "JMD", Exp: 2},
+		"JPY": {Code: "JPY", Symbol: "¥", Exp: 0},
+		"KES": {Code: "KES", Symbol: "KES", Exp: 2},
+		"KGS": {Code: "KGS", Symbol: "KGS", Exp: 2},
+		"KRW": {Code: "KRW", Symbol: "₩", Exp: 0},
+		"KZT": {Code: "KZT", Symbol: "KZT", Exp: 2},
+		"LBP": {Code: "LBP", Symbol: "LBP", Exp: 2},
+		"LKR": {Code: "LKR", Symbol: "LKR", Exp: 2},
+		"MAD": {Code: "MAD", Symbol: "MAD", Exp: 2},
+		"MDL": {Code: "MDL", Symbol: "MDL", Exp: 2},
+		"MNT": {Code: "MNT", Symbol: "MNT", Exp: 2},
+		"MUR": {Code: "MUR", Symbol: "MUR", Exp: 2},
+		"MVR": {Code: "MVR", Symbol: "MVR", Exp: 2},
+		"MXN": {Code: "MXN", Symbol: "MX$", Exp: 2},
+		"MYR": {Code: "MYR", Symbol: "MYR", Exp: 2},
+		"MZN": {Code: "MZN", Symbol: "MZN", Exp: 2},
+		"NGN": {Code: "NGN", Symbol: "NGN", Exp: 2},
+		"NIO": {Code: "NIO", Symbol: "NIO", Exp: 2},
+		"NOK": {Code: "NOK", Symbol: "NOK", Exp: 2},
+		"NPR": {Code: "NPR", Symbol: "NPR", Exp: 2},
+		"NZD": {Code: "NZD", Symbol: "NZ$", Exp: 2},
+		"PAB": {Code: "PAB", Symbol: "PAB", Exp: 2},
+		"PEN": {Code: "PEN", Symbol: "PEN", Exp: 2},
+		"PHP": {Code: "PHP", Symbol: "PHP", Exp: 2},
+		"PKR": {Code: "PKR", Symbol: "PKR", Exp: 2},
+		"PLN": {Code: "PLN", Symbol: "PLN", Exp: 2},
+		"PYG": {Code: "PYG", Symbol: "PYG", Exp: 0},
+		"QAR": {Code: "QAR", Symbol: "QAR", Exp: 2},
+		"RON": {Code: "RON", Symbol: "RON", Exp: 2},
+		"RSD": {Code: "RSD", Symbol: "RSD", Exp: 2},
+		"RUB": {Code: "RUB", Symbol: "RUB", Exp: 2},
+		"SAR": {Code: "SAR", Symbol: "SAR", Exp: 2},
+		"SEK": {Code: "SEK", Symbol: "SEK", Exp: 2},
+		"SGD": {Code: "SGD", Symbol: "SGD", Exp: 2},
+		"THB": {Code: "THB", Symbol: "฿", Exp: 2},
+		"TJS": {Code: "TJS", Symbol: "TJS", Exp: 2},
+		"TRY": {Code: "TRY", Symbol: "TRY", Exp: 2},
+		"TTD": {Code: "TTD", Symbol: "TTD", Exp: 2},
+		"TWD": {Code: "TWD", Symbol: "NT$", Exp: 2},
+		"TZS": {Code: "TZS", Symbol: "TZS", Exp: 2},
+		"UAH": {Code: "UAH", Symbol: "UAH", Exp: 2},
+		"UGX": {Code: "UGX", Symbol: "UGX", Exp: 0},
+		"USD": {Code: "USD", Symbol: "$", Exp: 2, MinAmount: 100, MaxAmount: 1000000},
+		"UYU": {Code: "UYU", Symbol: "UYU", Exp: 2},
+		"UZS": {Code: "UZS", Symbol: "UZS", Exp: 2},
+		"VND": {Code: "VND", Symbol: "₫", Exp: 0},
+		"YER": {Code: "YER", Symbol: "YER", Exp: 2},
+		"ZAR": {Code: "ZAR", Symbol: "ZAR", Exp: 2},
+	}
+)
+
+func GetCurrency(code string) (*Currency, bool) {
+	currenciesMu.RLock()
+	defer currenciesMu.RUnlock()
+	currency, ok := currencies[code]
+	return currency, ok
+}
+
+func SetCurrency(currency *Currency) {
+	currenciesMu.Lock()
+	defer currenciesMu.Unlock()
+	currencies[currency.Code] = currency
+}
+
+// LoadCurrencies updates the currency table from the JSON published by Telegram
+// https://core.telegram.org/bots/payments/currencies.json
+func LoadCurrencies(r io.Reader) error {
+	var table map[string]*struct {
+		Code      string `json:"code"`
+		Symbol    string `json:"symbol"`
+		Exp       int    `json:"exp"`
+		MinAmount string `json:"min_amount"`
+		MaxAmount string `json:"max_amount"`
+	}
+	if err := json.NewDecoder(r).Decode(&table); err != nil {
+		return err
+	}
+	for code, entry := range table {
+		minAmount, err := strconv.Atoi(entry.MinAmount)
+		if err != nil {
+			return fmt.Errorf("currency %s: %s", code, err)
+		}
+		maxAmount, err := strconv.Atoi(entry.MaxAmount)
+		if err != nil {
+			return fmt.Errorf("currency %s: %s", code, err)
+		}
+		SetCurrency(&Currency{
+			Code:      code,
+			Symbol:    entry.Symbol,
+			Exp:       entry.Exp,
+			MinAmount: minAmount,
+			MaxAmount: maxAmount,
+		})
+	}
+	return nil
+}
+
+func (c *Currency) unit() int {
+	unit := 1
+	for i := 0; i < c.Exp; i++ {
+		unit *= 10
+	}
+	return unit
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseAmount converts a decimal amount like "12.34" to minor units.
+func (c *Currency) ParseAmount(amount string) (int, error) {
+	amount = strings.TrimSpace(amount)
+	number := strings.TrimPrefix(amount, "-")
+	whole, fraction := number, ""
+	if idx := strings.Index(number, "."); idx != -1 {
+		whole, fraction = number[:idx], number[idx+1:]
+	}
+	if whole+fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return 0, fmt.Errorf("invalid amount %s", amount)
+	}
+	if len(fraction) > c.Exp {
+		return 0, fmt.Errorf("amount %s has more than %d decimal digits for %s", amount, c.Exp, c.Code)
+	}
+	fraction += strings.Repeat("0", c.Exp-len(fraction))
+	value, err := strconv.Atoi(whole + fraction)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %s", amount)
+	}
+	if number != amount {
+		value = -value
+	}
+	return value, nil
+}
+
+func (c *Currency) FromFloat(amount float64) int {
+	return int(math.Round(amount * float64(c.unit())))
+}
+
+func (c *Currency) ToFloat(amount int) float64 {
+	return float64(amount) / float64(c.unit())
+}
+
+// FormatAmount formats minor units as a decimal amount without a symbol.
+func (c *Currency) FormatAmount(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if c.Exp == 0 {
+		return sign + strconv.Itoa(amount)
+	}
+	unit := c.unit()
+	fraction := strconv.Itoa(amount % unit)
+	fraction = strings.Repeat("0", c.Exp-len(fraction)) + fraction
+	return sign + strconv.Itoa(amount/unit) + "." + fraction
+}
+
+// Format formats minor units for display, e.g. "$12.34".
+func (c *Currency) Format(amount int) string {
+	formatted := c.FormatAmount(amount)
+	if strings.HasPrefix(formatted, "-") {
+		return "-" + c.Symbol + formatted[1:]
+	}
+	return c.Symbol + formatted
+}
+
+func (c *Currency) CheckTotal(total int) error {
+	if total <= 0 {
+		return fmt.Errorf("total amount %s must be positive", c.Format(total))
+	}
+	if c.MinAmount > 0 && total < c.MinAmount {
+		return fmt.Errorf("total amount %s is less than %s", c.Format(total), c.Format(c.MinAmount))
+	}
+	if c.MaxAmount > 0 && total > c.MaxAmount {
+		return fmt.Errorf("total amount %s is greater than %s", c.Format(total), c.Format(c.MaxAmount))
+	}
+	return nil
+}
+
+func sumPrices(prices []*LabeledPrice) int {
+	total := 0
+	for _, price := range prices {
+		total += price.Amount
+	}
+	return total
+}
+
+func validateInvoicePrices(currencyCode string, prices []*LabeledPrice) error {
+	currency, ok := GetCurrency(currencyCode)
+	if !ok {
+		return fmt.Errorf("unsupported currency %s", currencyCode)
+	}
+	if len(prices) == 0 {
+		return errors.New("invoice must have at least one price")
+	}
+	return currency.CheckTotal(sumPrices(prices))
+}
+
+func validateShippingOptions(currencyCode string, options []*ShippingOption) error {
+	var currency *Currency
+	if currencyCode != "" {
+		var ok bool
+		if currency, ok = GetCurrency(currencyCode); !ok {
+			return fmt.Errorf("unsupported currency %s", currencyCode)
+		}
+	}
+	for _, option := range options {
+		if len(option.Prices) == 0 {
+			return fmt.Errorf("shipping option %s must have at least one price", option.ID)
+		}
+		total := sumPrices(option.Prices)
+		if total < 0 {
+			return fmt.Errorf("shipping option %s has negative total", option.ID)
+		}
+		if currency != nil && currency.MaxAmount > 0 && total > currency.MaxAmount {
+			return fmt.Errorf("shipping option %s total %s is greater than %s",
+				option.ID, currency.Format(total), currency.Format(currency.MaxAmount))
+		}
+	}
+	return nil
+}
+
+func (p *SendInvoiceArgs) validate() error {
+	return validateInvoicePrices(p.Currency, p.Prices)
+}
+
+func (p *AnswerShippingQueryArgs) validate() error {
+	if !p.Ok {
+		return nil
+	}
+	if len(p.ShippingOptions) == 0 {
+		return errors.New("shipping options are required when ok is true")
+	}
+	return validateShippingOptions(p.Currency, p.ShippingOptions)
+}
@@ -0,0 +1,57 @@
+package tg_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"testing"
+)
+
+func TestCurrencyAmounts(t *testing.T) {
+	usd, _ := tg.GetCurrency("USD")
+	jpy, _ := tg.GetCurrency("JPY")
+	tests := []struct {
+		currency  *tg.Currency
+		amount    string
+		minor     int
+		formatted string
+	}{
+		{currency: usd, amount: "12.34", minor: 1234, formatted: "$12.34"},
+		{currency: usd, amount: "12.3", minor: 1230, formatted: "$12.30"},
+		{currency: usd, amount: "0.05", minor: 5, formatted: "$0.05"},
+		{currency: usd, amount: "-1.5", minor: -150, formatted: "-$1.50"},
+		{currency: jpy, amount: "1234", minor: 1234, formatted: "¥1234"},
+	}
+	for _, test := range tests {
+		minor, err := test.currency.ParseAmount(test.amount)
+		assert.Nil(t, err)
+		assert.Equal(t, minor, test.minor)
+		assert.Equal(t, test.currency.Format(minor), test.formatted)
+	}
+	_, err := jpy.ParseAmount("12.34")
+	assert.NotNil(t, err)
+	for _, amount := range []string{"", "-", ".", "--5", "+5", "-+5", "5.-1", "5.+1", "1 000", "1e3", "1.2.3"} {
+		_, err = usd.ParseAmount(amount)
+		assert.NotNil(t, err, amount)
+	}
+	assert.Equal(t, usd.FromFloat(19.99), 1999)
+	assert.Equal(t, jpy.FromFloat(500), 500)
+}
+
+func TestSendInvoiceValidation(t *testing.T) {
+	api := &tg.API{Token: "TOKEN", Client: new(HttpClientMock)}
+	tests := []*tg.SendInvoiceArgs{
+		{Currency: "XXX", Prices: []*tg.LabeledPrice{{Label: "Item", Amount: 1000}}},
+		{Currency: "USD"},
+		{Currency: "USD", Prices: []*tg.LabeledPrice{{Label: "Item", Amount: 10}}},
+		{Currency: "USD", Prices: []*tg.LabeledPrice{{Label: "Item", Amount: 100000000}}},
+	}
+	for _, args := range tests {
+		_, err := api.SendInvoice(args)
+		assert.IsType(t, &tg.BuildRequestError{}, err)
+	}
+	_, err := api.AnswerShippingQuery(&tg.AnswerShippingQueryArgs{
+		Ok:              true,
+		ShippingOptions: []*tg.ShippingOption{{ID: "post"}},
+	})
+	assert.IsType(t, &tg.BuildRequestError{}, err)
+}
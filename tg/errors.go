@@ -43,7 +43,9 @@ func NewParseResponseBodyError(message string) *ParseResponseBodyError {
 }
 
 type APIError struct {
-	message string
+	message    string
+	Code       int
+	Parameters *ResponseParameters
 }
 
 func (e *APIError) Error() string {
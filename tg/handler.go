@@ -0,0 +1,35 @@
+package tg
+
+// UpdateHandler processes an update. It returns false if the update is not
+// handled and should be passed to the next handler.
+type UpdateHandler interface {
+	HandleUpdate(update *Update) (bool, error)
+}
+
+// AsyncUpdateHandler is an UpdateHandler which handles updates after
+// HandleUpdateAsync returns, e.g. WorkerPool. If the update is handled, done
+// is called when its handling is finished. Poller uses it to save the offset
+// of finished updates only.
+type AsyncUpdateHandler interface {
+	UpdateHandler
+	HandleUpdateAsync(update *Update, done func()) (bool, error)
+}
+
+type UpdateHandlerFunc func(update *Update) (bool, error)
+
+func (f UpdateHandlerFunc) HandleUpdate(update *Update) (bool, error) {
+	return f(update)
+}
+
+// Handlers passes an update to the handlers in their order until one of them handles it.
+type Handlers []UpdateHandler
+
+func (h Handlers) HandleUpdate(update *Update) (bool, error) {
+	for _, handler := range h {
+		handled, err := handler.HandleUpdate(update)
+		if handled || err != nil {
+			return handled, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,164 @@
+package tg
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	InlineQueryMaxResults = 50
+	InlineQueryCacheTime  = 300 // seconds, the default of Telegram
+	InlineQueryCacheSize  = 1000
+)
+
+// InlineResultProvider returns at most limit results of the query starting at offset.
+type InlineResultProvider func(query string, offset int, limit int, user *User) ([]interface{}, error)
+
+type inlinePage struct {
+	results    []interface{}
+	nextOffset string
+	expires    time.Time
+}
+
+// InlineResponder answers inline queries with pages of results of the Provider.
+// Pages are cached locally for CacheTime, by user too if IsPersonal is set.
+type InlineResponder struct {
+	API               *API
+	Provider          InlineResultProvider
+	PageSize          int  // Optional, InlineQueryMaxResults by default
+	CacheTime         int  // Optional, seconds, InlineQueryCacheTime by default
+	IsPersonal        bool // results depend on the user
+	CacheSize         int  // Optional, number of cached pages, InlineQueryCacheSize by default
+	SwitchPmText      string
+	SwitchPmParameter string
+	mu                sync.Mutex
+	cache             map[string]*inlinePage
+}
+
+func (r *InlineResponder) getPageSize() int {
+	if r.PageSize > 0 && r.PageSize < InlineQueryMaxResults {
+		return r.PageSize
+	}
+	return InlineQueryMaxResults
+}
+
+func (r *InlineResponder) getCacheTime() int {
+	if r.CacheTime > 0 {
+		return r.CacheTime
+	}
+	return InlineQueryCacheTime
+}
+
+func (r *InlineResponder) getCacheSize() int {
+	if r.CacheSize > 0 {
+		return r.CacheSize
+	}
+	return InlineQueryCacheSize
+}
+
+func encodeInlineOffset(offset int) string {
+	return strconv.FormatInt(int64(offset), 36)
+}
+
+// decodeInlineOffset returns 0 for the first page and invalid offsets.
+func decodeInlineOffset(offset string) int {
+	value, err := strconv.ParseInt(offset, 36, 0)
+	if err != nil || value < 0 {
+		return 0
+	}
+	return int(value)
+}
+
+func (r *InlineResponder) cacheKey(query *InlineQuery, offset int) string {
+	key := strconv.Itoa(offset) + ":" + query.Query
+	if r.IsPersonal && query.From != nil {
+		key = strconv.Itoa(query.From.ID) + ":" + key
+	}
+	return key
+}
+
+func (r *InlineResponder) cached(key string, now time.Time) *inlinePage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	page, ok := r.cache[key]
+	if !ok || now.After(page.expires) {
+		return nil
+	}
+	return page
+}
+
+func (r *InlineResponder) store(key string, page *inlinePage, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cache == nil {
+		r.cache = make(map[string]*inlinePage)
+	}
+	if len(r.cache) >= r.getCacheSize() {
+		var oldest string
+		for k, p := range r.cache {
+			if now.After(p.expires) {
+				delete(r.cache, k)
+			} else if oldest == "" || p.expires.Before(r.cache[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(r.cache) >= r.getCacheSize() {
+			delete(r.cache, oldest)
+		}
+	}
+	r.cache[key] = page
+}
+
+func (r *InlineResponder) page(query *InlineQuery) (*inlinePage, error) {
+	offset := decodeInlineOffset(query.Offset)
+	key := r.cacheKey(query, offset)
+	now := time.Now()
+	if page := r.cached(key, now); page != nil {
+		return page, nil
+	}
+	size := r.getPageSize()
+	results, err := r.Provider(query.Query, offset, size+1, query.From)
+	if err != nil {
+		return nil, err
+	}
+	page := &inlinePage{
+		results: results,
+		expires: now.Add(time.Duration(r.getCacheTime()) * time.Second),
+	}
+	if len(results) > size {
+		page.results = results[:size]
+		page.nextOffset = encodeInlineOffset(offset + size)
+	}
+	r.store(key, page, now)
+	return page, nil
+}
+
+// Answer answers the query with the page of results requested by its offset.
+func (r *InlineResponder) Answer(query *InlineQuery) error {
+	page, err := r.page(query)
+	if err != nil {
+		return err
+	}
+	results := page.results
+	if results == nil {
+		results = []interface{}{}
+	}
+	_, err = r.API.AnswerInlineQuery(&AnswerInlineQueryArgs{
+		InlineQueryID:     query.ID,
+		Results:           results,
+		CacheTime:         r.getCacheTime(),
+		IsPersonal:        r.IsPersonal,
+		NextOffset:        page.nextOffset,
+		SwitchPmText:      r.SwitchPmText,
+		SwitchPmParameter: r.SwitchPmParameter,
+	})
+	return err
+}
+
+func (r *InlineResponder) HandleUpdate(update *Update) (bool, error) {
+	if update.InlineQuery == nil {
+		return false, nil
+	}
+	return true, r.Answer(update.InlineQuery)
+}
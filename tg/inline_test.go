@@ -0,0 +1,64 @@
+package tg_test
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/websuslik/unibot/tg"
+	"strconv"
+	"testing"
+)
+
+func TestInlineResponder(t *testing.T) {
+	var answers []map[string]interface{}
+	m := new(HttpClientMock)
+	m.On("Do", "https://api.telegram.org/botTOKEN/answerInlineQuery", mock.MatchedBy(func(args *tg.RequestArgs) bool {
+		var answer map[string]interface{}
+		_ = json.Unmarshal(args.Body.Bytes(), &answer)
+		answers = append(answers, answer)
+		return true
+	}), mock.Anything).Return([]byte(`{"ok":true,"result":true}`), nil)
+
+	calls := 0
+	responder := &tg.InlineResponder{
+		API: &tg.API{Token: "TOKEN", Client: m},
+		Provider: func(query string, offset int, limit int, user *tg.User) ([]interface{}, error) {
+			calls++
+			var results []interface{}
+			for i := offset; i < 120 && i < offset+limit; i++ {
+				results = append(results, &tg.InlineQueryResultArticle{
+					Type:  "article",
+					ID:    strconv.Itoa(i),
+					Title: query,
+				})
+			}
+			return results, nil
+		},
+		IsPersonal: true,
+	}
+	query := &tg.InlineQuery{ID: "1", From: &tg.User{ID: 123}, Query: "cats"}
+
+	handled, err := responder.HandleUpdate(&tg.Update{InlineQuery: query})
+	assert.True(t, handled)
+	assert.Nil(t, err)
+	assert.Len(t, answers[0]["results"], 50)
+	assert.Equal(t, float64(300), answers[0]["cache_time"])
+	assert.Equal(t, true, answers[0]["is_personal"])
+	nextOffset := answers[0]["next_offset"].(string)
+
+	// the same page is answered from the cache
+	assert.Nil(t, responder.Answer(query))
+	assert.Equal(t, 1, calls)
+
+	for _, offset := range []string{nextOffset, "2s"} {
+		assert.Nil(t, responder.Answer(&tg.InlineQuery{ID: "2", From: &tg.User{ID: 123}, Query: "cats", Offset: offset}))
+	}
+	assert.Len(t, answers[2]["results"], 50)
+	assert.Equal(t, "2s", answers[2]["next_offset"])
+	assert.Len(t, answers[3]["results"], 20)
+	assert.Nil(t, answers[3]["next_offset"])
+
+	// the results are personal
+	assert.Nil(t, responder.Answer(&tg.InlineQuery{ID: "3", From: &tg.User{ID: 456}, Query: "cats"}))
+	assert.Equal(t, 4, calls)
+}
@@ -0,0 +1,31 @@
+package tg
+
+// Invocation is a method call passing through the interceptors of API.
+type Invocation struct {
+	Method   string
+	Args     MethodArgs
+	Request  *RequestArgs // the body is consumed when the request is sent
+	Response []byte       // raw response, set when the response is received
+	decoded  bool
+	api      *API
+}
+
+type Invoker func(inv *Invocation) error
+
+// Interceptor is called with the built request instead of sending it.
+// It calls next to send the request and decode the response, the returned
+// error is the error of the method, including APIError. An interceptor can
+// also change the request, set the Response without calling next, e.g.
+// from a cache, or return its own error.
+type Interceptor func(inv *Invocation, next Invoker) error
+
+func (api *API) intercept(inv *Invocation, invoker Invoker) error {
+	next := invoker
+	for i := len(api.Interceptors) - 1; i >= 0; i-- {
+		interceptor, invoke := api.Interceptors[i], next
+		next = func(inv *Invocation) error {
+			return interceptor(inv, invoke)
+		}
+	}
+	return next(inv)
+}
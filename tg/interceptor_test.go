@@ -0,0 +1,53 @@
+package tg_test
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"testing"
+)
+
+func TestInterceptors(t *testing.T) {
+	m, api := setUpMock("getMe", map[string]interface{}{
+		"ok":          false,
+		"error_code":  401,
+		"description": "Unauthorized",
+	})
+	var calls []string
+	var seen error
+	api.Interceptors = []tg.Interceptor{
+		func(inv *tg.Invocation, next tg.Invoker) error {
+			calls = append(calls, "outer "+inv.Method)
+			seen = next(inv)
+			return seen
+		},
+		func(inv *tg.Invocation, next tg.Invoker) error {
+			calls = append(calls, "inner "+inv.Method)
+			assert.NotNil(t, inv.Request)
+			err := next(inv)
+			assert.Contains(t, string(inv.Response), "Unauthorized")
+			return err
+		},
+	}
+	_, err := api.GetMe(&tg.GetMeArgs{})
+	m.AssertExpectations(t)
+	assert.Equal(t, []string{"outer getMe", "inner getMe"}, calls)
+	assert.IsType(t, &tg.APIError{}, seen)
+	assert.Equal(t, seen, err)
+}
+
+func TestInterceptorResponse(t *testing.T) {
+	m := new(HttpClientMock)
+	api := &tg.API{Token: "TOKEN", Client: m}
+	cached, _ := json.Marshal(map[string]interface{}{"ok": true, "result": commonUser})
+	api.Interceptors = []tg.Interceptor{
+		func(inv *tg.Invocation, next tg.Invoker) error {
+			inv.Response = cached
+			return nil
+		},
+	}
+	res, err := api.GetMe(&tg.GetMeArgs{})
+	assert.Nil(t, err)
+	assert.Equal(t, "Yuri", res.FirstName)
+	m.AssertNotCalled(t, "Do")
+}
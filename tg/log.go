@@ -0,0 +1,47 @@
+package tg
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// chatIDOf returns the chat of the method args, empty if there is no chat.
+func chatIDOf(args MethodArgs) string {
+	chatID := argsChatID(args)
+	switch {
+	case chatID == nil:
+		return ""
+	case chatID.ID != 0:
+		return strconv.Itoa(chatID.ID)
+	}
+	return chatID.Username
+}
+
+// LogInterceptor logs every method call with its chat, duration and outcome.
+// Errors are logged with the token redacted.
+func LogInterceptor(logger *slog.Logger) Interceptor {
+	return func(inv *Invocation, next Invoker) error {
+		start := time.Now()
+		err := next(inv)
+		attrs := []slog.Attr{
+			slog.String("method", inv.Method),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if chatID := chatIDOf(inv.Args); chatID != "" {
+			attrs = append(attrs, slog.String("chat_id", chatID))
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("outcome", ErrorCategory(err)), slog.String("error", err.Error()))
+			if apiErr, ok := err.(*APIError); ok && apiErr.Code != 0 {
+				attrs = append(attrs, slog.Int("error_code", apiErr.Code))
+			}
+			logger.LogAttrs(context.Background(), slog.LevelWarn, "bot api call failed", attrs...)
+		} else {
+			attrs = append(attrs, slog.String("outcome", "ok"))
+			logger.LogAttrs(context.Background(), slog.LevelInfo, "bot api call", attrs...)
+		}
+		return err
+	}
+}
@@ -0,0 +1,53 @@
+package tg_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/websuslik/unibot/tg"
+	"log/slog"
+	"testing"
+)
+
+func TestRedactToken(t *testing.T) {
+	m := new(HttpClientMock)
+	m.On("Do", mock.Anything, mock.Anything, mock.Anything).Return([]byte(nil),
+		errors.New(`Post "https://api.telegram.org/bot123:SECRET/getMe": dial tcp: i/o timeout`))
+	api := &tg.API{Token: "123:SECRET", Client: m}
+	buf := &bytes.Buffer{}
+	api.Interceptors = []tg.Interceptor{tg.LogInterceptor(slog.New(slog.NewJSONHandler(buf, nil)))}
+
+	_, err := api.GetMe(&tg.GetMeArgs{})
+	assert.IsType(t, &tg.SendRequestError{}, err)
+	assert.NotContains(t, err.Error(), "SECRET")
+	assert.Contains(t, err.Error(), "bot<token>/getMe")
+	assert.NotContains(t, buf.String(), "SECRET")
+	assert.Contains(t, buf.String(), `"outcome":"send"`)
+}
+
+func TestLogInterceptor(t *testing.T) {
+	m, api := setUpMock("editMessageText", map[string]interface{}{
+		"ok":     true,
+		"result": commonMessage,
+	})
+	buf := &bytes.Buffer{}
+	api.Interceptors = []tg.Interceptor{tg.LogInterceptor(slog.New(slog.NewJSONHandler(buf, nil)))}
+	_, err := api.EditMessageText(&tg.EditMessageTextArgs{
+		MessageRef: &tg.MessageRef{ChatID: &tg.ChatID{ID: 123}, MessageID: 1},
+		Text:       "Hello, World!",
+	})
+	m.AssertExpectations(t)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `"method":"editMessageText"`)
+	assert.Contains(t, buf.String(), `"chat_id":"123"`)
+	assert.Contains(t, buf.String(), `"outcome":"ok"`)
+
+	buf.Reset()
+	_, err = api.EditMessageText(&tg.EditMessageTextArgs{
+		MessageRef: tg.NewInlineMessageRef("abc"),
+		Text:       "Hello, World!",
+	})
+	assert.Nil(t, err)
+	assert.NotContains(t, buf.String(), "chat_id")
+}
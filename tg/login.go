@@ -0,0 +1,82 @@
+package tg
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const LoginMaxAge = 24 * time.Hour
+
+type loginContextKey struct{}
+
+// loginFields are the fields signed by Telegram in alphabetical order. Other
+// parameters, e.g. of the query of a LoginURL, are not part of the data.
+var loginFields = []string{"auth_date", "first_name", "id", "last_name", "photo_url", "username"}
+
+func buildLoginDataCheckString(values url.Values) string {
+	lines := make([]string, 0, len(loginFields))
+	for _, key := range loginFields {
+		if _, ok := values[key]; ok {
+			lines = append(lines, key+"="+values.Get(key))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// https://core.telegram.org/widgets/login#checking-authorization
+func CheckLoginData(token string, values url.Values, maxAge time.Duration) (*User, error) {
+	hash, err := hex.DecodeString(values.Get("hash"))
+	if err != nil || len(hash) == 0 {
+		return nil, errors.New("login data: hash is missing or malformed")
+	}
+	secretKey := sha256.Sum256([]byte(token))
+	mac := hmac.New(sha256.New, secretKey[:])
+	mac.Write([]byte(buildLoginDataCheckString(values)))
+	if !hmac.Equal(mac.Sum(nil), hash) {
+		return nil, errors.New("login data: hash mismatch")
+	}
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, errors.New("login data: auth_date is missing or malformed")
+	}
+	if maxAge > 0 && time.Since(time.Unix(authDate, 0)) > maxAge {
+		return nil, errors.New("login data: auth_date is outdated")
+	}
+	id, err := strconv.Atoi(values.Get("id"))
+	if err != nil {
+		return nil, errors.New("login data: id is missing or malformed")
+	}
+	return &User{
+		ID:        id,
+		FirstName: values.Get("first_name"),
+		LastName:  values.Get("last_name"),
+		Username:  values.Get("username"),
+	}, nil
+}
+
+// LoginMiddleware checks the login data Telegram passes in the query of a
+// LoginURL or Login Widget redirect and rejects the request if it is not valid.
+// The authorized user is available through LoginUserFromContext.
+func LoginMiddleware(token string, maxAge time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := CheckLoginData(token, r.URL.Query(), maxAge)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), loginContextKey{}, user)))
+	})
+}
+
+func LoginUserFromContext(ctx context.Context) *User {
+	user, _ := ctx.Value(loginContextKey{}).(*User)
+	return user
+}
@@ -0,0 +1,77 @@
+package tg_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signLoginData(token string, values url.Values) url.Values {
+	secretKey := sha256.Sum256([]byte(token))
+	mac := hmac.New(sha256.New, secretKey[:])
+	mac.Write([]byte("auth_date=" + values.Get("auth_date") + "\nfirst_name=" + values.Get("first_name") +
+		"\nid=" + values.Get("id") + "\nusername=" + values.Get("username")))
+	values.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+	return values
+}
+
+func loginValues(authDate time.Time) url.Values {
+	return url.Values{
+		"id":         {"123"},
+		"first_name": {"Yuri"},
+		"username":   {"yuri"},
+		"auth_date":  {strconv.FormatInt(authDate.Unix(), 10)},
+	}
+}
+
+func TestCheckLoginData(t *testing.T) {
+	values := signLoginData("TOKEN", loginValues(time.Now()))
+	user, err := tg.CheckLoginData("TOKEN", values, tg.LoginMaxAge)
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, 123)
+	assert.Equal(t, user.FirstName, "Yuri")
+	assert.Equal(t, user.Username, "yuri")
+
+	_, err = tg.CheckLoginData("OTHER", values, tg.LoginMaxAge)
+	assert.NotNil(t, err)
+
+	values.Set("first_name", "Ivan")
+	_, err = tg.CheckLoginData("TOKEN", values, tg.LoginMaxAge)
+	assert.NotNil(t, err)
+
+	outdated := signLoginData("TOKEN", loginValues(time.Now().Add(-48*time.Hour)))
+	_, err = tg.CheckLoginData("TOKEN", outdated, tg.LoginMaxAge)
+	assert.NotNil(t, err)
+}
+
+func TestLoginMiddleware(t *testing.T) {
+	var user *tg.User
+	handler := tg.LoginMiddleware("TOKEN", tg.LoginMaxAge, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = tg.LoginUserFromContext(r.Context())
+	}))
+
+	values := signLoginData("TOKEN", loginValues(time.Now()))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/login?"+values.Encode(), nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, user.ID, 123)
+
+	// parameters of the redirect URL are not signed by Telegram
+	user = nil
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/login?next=%2Fcart&"+values.Encode(), nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, user.ID, 123)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/login?id=123", nil))
+	assert.Equal(t, recorder.Code, http.StatusForbidden)
+}
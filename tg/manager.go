@@ -0,0 +1,238 @@
+package tg
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	BotModePolling = "polling"
+	BotModeWebhook = "webhook"
+)
+
+type BotHealth struct {
+	Name        string    `json:"name"`
+	Mode        string    `json:"mode"`
+	Running     bool      `json:"running"` // the poller is running, always true for webhooks
+	Updates     int       `json:"updates"`
+	Errors      int       `json:"errors"`
+	LastUpdate  time.Time `json:"last_update"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at"`
+}
+
+// Bot is a bot of BotManager, either Poller or Webhook is set.
+type Bot struct {
+	Name    string
+	API     *API
+	Poller  *Poller
+	Webhook *WebhookHandler
+	mu      sync.Mutex
+	health  BotHealth
+	onError func(bot *Bot, err error)
+}
+
+func (b *Bot) Health() BotHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.health
+}
+
+func (b *Bot) recordUpdate(update *Update) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.health.Updates++
+	b.health.LastUpdate = time.Now()
+}
+
+func (b *Bot) recordError(err error) {
+	b.mu.Lock()
+	b.health.Errors++
+	b.health.LastError = err.Error()
+	b.health.LastErrorAt = time.Now()
+	b.mu.Unlock()
+	if b.onError != nil {
+		b.onError(b, err)
+	}
+}
+
+func (b *Bot) setRunning(running bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.health.Running = running
+}
+
+// isolate recovers panics of the handler, so a bot can not break the others.
+// Asynchronous handlers are returned as is: their HandleUpdateAsync only queues
+// updates, so they have to recover panics of their goroutines, as WorkerPool does.
+func isolate(handler UpdateHandler) UpdateHandler {
+	if _, ok := handler.(AsyncUpdateHandler); ok {
+		return handler
+	}
+	return UpdateHandlerFunc(func(update *Update) (handled bool, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("handler panic: %v", r)
+			}
+		}()
+		return handler.HandleUpdate(update)
+	})
+}
+
+// BotManager runs several bots in one process. The bots share one HTTP
+// transport and the manager is the webhook handler of all webhook bots:
+// a request is routed to the bot named by the last path element, e.g.
+// /telegram/<name>, or to the bot with the secret token of the request.
+type BotManager struct {
+	Transport http.RoundTripper         // Optional, shared by the bots, a new http.Transport by default
+	OnError   func(bot *Bot, err error) // Optional
+	mu        sync.Mutex
+	bots      map[string]*Bot
+}
+
+func (m *BotManager) add(name string, token string) (*Bot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid bot name %q", name)
+	}
+	if _, ok := m.bots[name]; ok {
+		return nil, fmt.Errorf("bot %s already exists", name)
+	}
+	if m.bots == nil {
+		m.bots = make(map[string]*Bot)
+	}
+	if m.Transport == nil {
+		m.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	bot := &Bot{
+		Name:    name,
+		API:     &API{Token: token, Client: &DefaultHttpClient{Transport: m.Transport}},
+		health:  BotHealth{Name: name},
+		onError: m.OnError,
+	}
+	m.bots[name] = bot
+	return bot, nil
+}
+
+// AddPolling adds a bot receiving updates with a Poller started by Run.
+func (m *BotManager) AddPolling(name string, token string, handler UpdateHandler) (*Bot, error) {
+	bot, err := m.add(name, token)
+	if err != nil {
+		return nil, err
+	}
+	bot.health.Mode = BotModePolling
+	bot.Poller = &Poller{
+		API:      bot.API,
+		Handler:  isolate(handler),
+		OnError:  bot.recordError,
+		OnUpdate: bot.recordUpdate,
+	}
+	return bot, nil
+}
+
+// AddWebhook adds a bot receiving updates with the webhook of the manager.
+// The secret token is set for Telegram by SetWebhookArgs.SecretToken.
+func (m *BotManager) AddWebhook(name string, token string, secretToken string, handler UpdateHandler) (*Bot, error) {
+	bot, err := m.add(name, token)
+	if err != nil {
+		return nil, err
+	}
+	bot.health.Mode = BotModeWebhook
+	bot.health.Running = true
+	bot.Webhook = &WebhookHandler{
+		Handler:     isolate(handler),
+		SecretToken: secretToken,
+		OnError:     bot.recordError,
+		OnUpdate:    bot.recordUpdate,
+	}
+	return bot, nil
+}
+
+func (m *BotManager) Bot(name string) *Bot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bots[name]
+}
+
+func (m *BotManager) sortedBots() []*Bot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bots := make([]*Bot, 0, len(m.bots))
+	for _, bot := range m.bots {
+		bots = append(bots, bot)
+	}
+	sort.Slice(bots, func(i, j int) bool {
+		return bots[i].Name < bots[j].Name
+	})
+	return bots
+}
+
+func (m *BotManager) route(r *http.Request) *Bot {
+	if bot := m.Bot(path.Base(r.URL.Path)); bot != nil && bot.Webhook != nil {
+		return bot
+	}
+	if token := r.Header.Get(SecretTokenHeader); token != "" {
+		for _, bot := range m.sortedBots() {
+			if bot.Webhook != nil && bot.Webhook.SecretToken != "" &&
+				subtle.ConstantTimeCompare([]byte(bot.Webhook.SecretToken), []byte(token)) == 1 {
+				return bot
+			}
+		}
+	}
+	return nil
+}
+
+func (m *BotManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bot := m.route(r)
+	if bot == nil {
+		http.NotFound(w, r)
+		return
+	}
+	bot.Webhook.ServeHTTP(w, r)
+}
+
+// Run runs the pollers of the polling bots until ctx is done.
+func (m *BotManager) Run(ctx context.Context) error {
+	wg := &sync.WaitGroup{}
+	for _, bot := range m.sortedBots() {
+		if bot.Poller == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(bot *Bot) {
+			defer wg.Done()
+			bot.setRunning(true)
+			defer bot.setRunning(false)
+			_ = bot.Poller.Run(ctx)
+		}(bot)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// Health returns the health of the bots ordered by name.
+func (m *BotManager) Health() []BotHealth {
+	bots := m.sortedBots()
+	health := make([]BotHealth, len(bots))
+	for i, bot := range bots {
+		health[i] = bot.Health()
+	}
+	return health
+}
+
+// HealthHandler serves the health of the bots as JSON.
+func (m *BotManager) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(m.Health())
+	})
+}
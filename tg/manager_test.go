@@ -0,0 +1,72 @@
+package tg_test
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postUpdate(handler http.Handler, path string, secretToken string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]interface{}{"update_id": 1, "message": commonMessage})
+	request := httptest.NewRequest("POST", path, strings.NewReader(string(body)))
+	if secretToken != "" {
+		request.Header.Set(tg.SecretTokenHeader, secretToken)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestBotManagerWebhook(t *testing.T) {
+	manager := &tg.BotManager{}
+	received := map[string]int{}
+	handler := func(name string) tg.UpdateHandler {
+		return tg.UpdateHandlerFunc(func(update *tg.Update) (bool, error) {
+			received[name]++
+			if name == "broken" {
+				panic("broken handler")
+			}
+			return true, nil
+		})
+	}
+	_, err := manager.AddWebhook("shop", "TOKEN1", "", handler("shop"))
+	assert.Nil(t, err)
+	_, err = manager.AddWebhook("news", "TOKEN2", "secret", handler("news"))
+	assert.Nil(t, err)
+	_, err = manager.AddWebhook("broken", "TOKEN3", "", handler("broken"))
+	assert.Nil(t, err)
+	_, err = manager.AddPolling("poll", "TOKEN4", handler("poll"))
+	assert.Nil(t, err)
+	_, err = manager.AddWebhook("shop", "TOKEN5", "", handler("shop"))
+	assert.Error(t, err)
+
+	assert.Equal(t, http.StatusOK, postUpdate(manager, "/telegram/shop", "").Code)
+	assert.Equal(t, http.StatusOK, postUpdate(manager, "/telegram/", "secret").Code)
+	assert.Equal(t, http.StatusNotFound, postUpdate(manager, "/telegram/", "wrong").Code)
+	assert.Equal(t, http.StatusNotFound, postUpdate(manager, "/telegram/", "secre").Code)
+	assert.Equal(t, http.StatusForbidden, postUpdate(manager, "/telegram/news", "wrong").Code)
+	assert.Equal(t, http.StatusOK, postUpdate(manager, "/telegram/broken", "").Code)
+	assert.Equal(t, http.StatusNotFound, postUpdate(manager, "/telegram/poll", "").Code)
+	assert.Equal(t, http.StatusNotFound, postUpdate(manager, "/telegram/unknown", "").Code)
+	assert.Equal(t, map[string]int{"shop": 1, "news": 1, "broken": 1}, received)
+
+	health := manager.Health()
+	assert.Len(t, health, 4)
+	assert.Equal(t, "broken", health[0].Name)
+	assert.Equal(t, 1, health[0].Errors)
+	assert.Contains(t, health[0].LastError, "broken handler")
+	assert.Equal(t, tg.BotModePolling, health[2].Mode)
+	assert.False(t, health[2].Running)
+	assert.Equal(t, 1, health[3].Updates)
+
+	shop := manager.Bot("shop")
+	assert.Equal(t, manager.Transport, shop.API.Client.(*tg.DefaultHttpClient).Transport)
+
+	recorder := httptest.NewRecorder()
+	manager.HealthHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/health", nil))
+	assert.Contains(t, recorder.Body.String(), `"name":"shop"`)
+}
@@ -0,0 +1,17 @@
+package tg
+
+type InputMedia interface {
+	getMedia() []*InputFile
+}
+
+type InputFile struct {
+	FileName string
+	Name     string
+}
+
+func (f *InputFile) isAllSet() bool {
+	if f != nil && f.Name != "" && f.FileName != "" {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package tg
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	MediaGroupWindow  = time.Second
+	MediaGroupMaxSize = 10
+)
+
+// Album is a media group received as several messages.
+type Album struct {
+	MediaGroupID    string
+	Chat            *Chat
+	Messages        []*Message // ordered by MessageID
+	Photos          [][]*PhotoSize
+	Videos          []*Video
+	Caption         string
+	CaptionEntities []*MessageEntity
+}
+
+func newAlbum(messages []*Message) *Album {
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].MessageID < messages[j].MessageID
+	})
+	album := &Album{
+		MediaGroupID: messages[0].MediaGroupID,
+		Chat:         messages[0].Chat,
+		Messages:     messages,
+	}
+	for _, message := range messages {
+		if len(message.Photo) > 0 {
+			album.Photos = append(album.Photos, message.Photo)
+		}
+		if message.Video != nil {
+			album.Videos = append(album.Videos, message.Video)
+		}
+		if album.Caption == "" && message.Caption != "" {
+			album.Caption = message.Caption
+			album.CaptionEntities = message.CaptionEntities
+		}
+	}
+	return album
+}
+
+type pendingAlbum struct {
+	messages []*Message
+	timer    *time.Timer
+}
+
+// MediaGroups buffers messages and channel posts sharing a MediaGroupID and
+// delivers them to Handler as one Album. The album is delivered when no new
+// messages of the group arrive within Window or MediaGroupMaxSize is reached.
+type MediaGroups struct {
+	Handler func(album *Album)
+	Window  time.Duration // Optional, MediaGroupWindow is used by default
+	mu      sync.Mutex
+	pending map[string]*pendingAlbum
+}
+
+func (g *MediaGroups) getWindow() time.Duration {
+	if g.Window > 0 {
+		return g.Window
+	}
+	return MediaGroupWindow
+}
+
+// HandleUpdate returns false for updates which are not a part of a media group.
+func (g *MediaGroups) HandleUpdate(update *Update) (bool, error) {
+	message := update.Message
+	if message == nil {
+		message = update.ChannelPost
+	}
+	if message == nil || message.MediaGroupID == "" {
+		return false, nil
+	}
+	g.add(message)
+	return true, nil
+}
+
+func (g *MediaGroups) add(message *Message) {
+	g.mu.Lock()
+	if g.pending == nil {
+		g.pending = make(map[string]*pendingAlbum)
+	}
+	id := message.MediaGroupID
+	album, ok := g.pending[id]
+	if !ok {
+		album = &pendingAlbum{}
+		g.pending[id] = album
+		album.timer = time.AfterFunc(g.getWindow(), func() {
+			g.deliver(id)
+		})
+	} else {
+		album.timer.Reset(g.getWindow())
+	}
+	album.messages = append(album.messages, message)
+	full := len(album.messages) >= MediaGroupMaxSize
+	g.mu.Unlock()
+	if full {
+		g.deliver(id)
+	}
+}
+
+func (g *MediaGroups) deliver(id string) {
+	g.mu.Lock()
+	album, ok := g.pending[id]
+	if ok {
+		album.timer.Stop()
+		delete(g.pending, id)
+	}
+	g.mu.Unlock()
+	if ok {
+		g.Handler(newAlbum(album.messages))
+	}
+}
+
+// Flush delivers all buffered albums immediately, e.g. before shutdown.
+func (g *MediaGroups) Flush() {
+	g.mu.Lock()
+	ids := make([]string, 0, len(g.pending))
+	for id := range g.pending {
+		ids = append(ids, id)
+	}
+	g.mu.Unlock()
+	for _, id := range ids {
+		g.deliver(id)
+	}
+}
@@ -0,0 +1,76 @@
+package tg_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"testing"
+	"time"
+)
+
+func albumMessage(id int, groupID string, caption string) *tg.Message {
+	return &tg.Message{
+		MessageID:    id,
+		Chat:         &tg.Chat{ID: 123, Type: "private"},
+		MediaGroupID: groupID,
+		Photo:        []*tg.PhotoSize{{FileID: "photo", Width: 90, Height: 90}},
+		Caption:      caption,
+	}
+}
+
+func TestMediaGroups(t *testing.T) {
+	albums := make(chan *tg.Album, 2)
+	groups := &tg.MediaGroups{
+		Window:  20 * time.Millisecond,
+		Handler: func(album *tg.Album) { albums <- album },
+	}
+	handlers := tg.Handlers{groups}
+
+	handled, err := handlers.HandleUpdate(&tg.Update{Message: &tg.Message{MessageID: 1, Text: "Hello, World!"}})
+	assert.Nil(t, err)
+	assert.False(t, handled)
+
+	for _, message := range []*tg.Message{
+		albumMessage(3, "a", ""),
+		albumMessage(2, "a", "Caption"),
+		albumMessage(4, "b", ""),
+	} {
+		handled, err = handlers.HandleUpdate(&tg.Update{Message: message})
+		assert.Nil(t, err)
+		assert.True(t, handled)
+	}
+	videoMessage := albumMessage(5, "a", "")
+	videoMessage.Photo = nil
+	videoMessage.Video = &tg.Video{FileID: "video"}
+	_, _ = groups.HandleUpdate(&tg.Update{ChannelPost: videoMessage})
+
+	received := map[string]*tg.Album{}
+	for i := 0; i < 2; i++ {
+		select {
+		case album := <-albums:
+			received[album.MediaGroupID] = album
+		case <-time.After(time.Second):
+			t.Fatal("album is not delivered")
+		}
+	}
+	album := received["a"]
+	assert.Len(t, album.Messages, 3)
+	assert.Equal(t, 2, album.Messages[0].MessageID)
+	assert.Len(t, album.Photos, 2)
+	assert.Len(t, album.Videos, 1)
+	assert.Equal(t, "Caption", album.Caption)
+	assert.Len(t, received["b"].Messages, 1)
+}
+
+func TestMediaGroupsFlush(t *testing.T) {
+	var albums []*tg.Album
+	groups := &tg.MediaGroups{
+		Window:  time.Hour,
+		Handler: func(album *tg.Album) { albums = append(albums, album) },
+	}
+	_, _ = groups.HandleUpdate(&tg.Update{Message: albumMessage(1, "a", "")})
+	_, _ = groups.HandleUpdate(&tg.Update{Message: albumMessage(2, "a", "")})
+	assert.Len(t, albums, 0)
+	groups.Flush()
+	assert.Len(t, albums, 1)
+	assert.Len(t, albums[0].Messages, 2)
+}
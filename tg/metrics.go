@@ -0,0 +1,212 @@
+package tg
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ErrorCategoryBuild     = "build"
+	ErrorCategorySend      = "send"
+	ErrorCategoryParse     = "parse"
+	ErrorCategoryAPI       = "api"
+	ErrorCategoryFloodWait = "flood_wait" // API error with retry_after
+)
+
+// ErrorCategory returns the category of an error returned by a method, empty for nil.
+func ErrorCategory(err error) string {
+	switch e := err.(type) {
+	case nil:
+		return ""
+	case *BuildRequestError:
+		return ErrorCategoryBuild
+	case *SendRequestError:
+		return ErrorCategorySend
+	case *ParseResponseBodyError:
+		return ErrorCategoryParse
+	case *APIError:
+		if e.Parameters != nil && e.Parameters.RetryAfter > 0 {
+			return ErrorCategoryFloodWait
+		}
+		return ErrorCategoryAPI
+	}
+	return "other"
+}
+
+type CallStats struct {
+	Duration     time.Duration
+	RequestSize  int // body size, 0 if the request is not built
+	ResponseSize int
+	Err          error
+}
+
+// Metrics observes every method call of API.
+type Metrics interface {
+	ObserveCall(method string, stats *CallStats)
+	// ObserveRetry is called when a call is repeated, e.g. after a flood wait.
+	ObserveRetry(method string)
+}
+
+func (api *API) observeRetry(method string) {
+	if api.Metrics != nil {
+		api.Metrics.ObserveRetry(method)
+	}
+}
+
+var (
+	DurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+	SizeBuckets     = []float64{128, 512, 1024, 4096, 16384, 65536, 262144, 1048576, 10485760}
+)
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(buckets []float64, value float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	for i, bound := range buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+type methodMetrics struct {
+	requests     uint64
+	retries      uint64
+	errors       map[string]uint64
+	duration     histogram
+	requestSize  histogram
+	responseSize histogram
+}
+
+// PrometheusMetrics collects metrics of method calls and serves them
+// in the Prometheus text exposition format.
+type PrometheusMetrics struct {
+	Namespace string // Optional, prefix of metric names, unibot by default
+	mu        sync.Mutex
+	methods   map[string]*methodMetrics
+}
+
+func (m *PrometheusMetrics) method(name string) *methodMetrics {
+	if m.methods == nil {
+		m.methods = make(map[string]*methodMetrics)
+	}
+	mm, ok := m.methods[name]
+	if !ok {
+		mm = &methodMetrics{errors: make(map[string]uint64)}
+		m.methods[name] = mm
+	}
+	return mm
+}
+
+func (m *PrometheusMetrics) ObserveCall(method string, stats *CallStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mm := m.method(method)
+	mm.requests++
+	if category := ErrorCategory(stats.Err); category != "" {
+		mm.errors[category]++
+	}
+	mm.duration.observe(DurationBuckets, stats.Duration.Seconds())
+	mm.requestSize.observe(SizeBuckets, float64(stats.RequestSize))
+	mm.responseSize.observe(SizeBuckets, float64(stats.ResponseSize))
+}
+
+func (m *PrometheusMetrics) ObserveRetry(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.method(method).retries++
+}
+
+func (m *PrometheusMetrics) getNamespace() string {
+	if m.Namespace != "" {
+		return m.Namespace
+	}
+	return "unibot"
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func writeHistogram(w io.Writer, name string, method string, buckets []float64, h *histogram) {
+	for i, bound := range buckets {
+		var count uint64
+		if h.counts != nil {
+			count = h.counts[i]
+		}
+		fmt.Fprintf(w, "%s_bucket{method=\"%s\",le=\"%s\"} %d\n", name, method, formatFloat(bound), count)
+	}
+	fmt.Fprintf(w, "%s_bucket{method=\"%s\",le=\"+Inf\"} %d\n", name, method, h.count)
+	fmt.Fprintf(w, "%s_sum{method=\"%s\"} %s\n", name, method, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count{method=\"%s\"} %d\n", name, method, h.count)
+}
+
+// Export writes the metrics in the Prometheus text exposition format.
+func (m *PrometheusMetrics) Export(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.methods))
+	for name := range m.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	prefix := m.getNamespace() + "_"
+
+	fmt.Fprintf(w, "# HELP %srequests_total Bot API method calls.\n# TYPE %srequests_total counter\n", prefix, prefix)
+	for _, name := range names {
+		fmt.Fprintf(w, "%srequests_total{method=\"%s\"} %d\n", prefix, labelReplacer.Replace(name), m.methods[name].requests)
+	}
+	fmt.Fprintf(w, "# HELP %serrors_total Failed Bot API method calls by error category.\n# TYPE %serrors_total counter\n", prefix, prefix)
+	for _, name := range names {
+		mm := m.methods[name]
+		categories := make([]string, 0, len(mm.errors))
+		for category := range mm.errors {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+		for _, category := range categories {
+			fmt.Fprintf(w, "%serrors_total{method=\"%s\",category=\"%s\"} %d\n", prefix, labelReplacer.Replace(name), category, mm.errors[category])
+		}
+	}
+	fmt.Fprintf(w, "# HELP %sretries_total Repeated Bot API method calls.\n# TYPE %sretries_total counter\n", prefix, prefix)
+	for _, name := range names {
+		fmt.Fprintf(w, "%sretries_total{method=\"%s\"} %d\n", prefix, labelReplacer.Replace(name), m.methods[name].retries)
+	}
+	histograms := []struct {
+		name    string
+		help    string
+		buckets []float64
+		get     func(mm *methodMetrics) *histogram
+	}{
+		{"request_duration_seconds", "Bot API method call latency.", DurationBuckets, func(mm *methodMetrics) *histogram { return &mm.duration }},
+		{"request_size_bytes", "Bot API request body size.", SizeBuckets, func(mm *methodMetrics) *histogram { return &mm.requestSize }},
+		{"response_size_bytes", "Bot API response body size.", SizeBuckets, func(mm *methodMetrics) *histogram { return &mm.responseSize }},
+	}
+	for _, h := range histograms {
+		fmt.Fprintf(w, "# HELP %s%s %s\n# TYPE %s%s histogram\n", prefix, h.name, h.help, prefix, h.name)
+		for _, name := range names {
+			writeHistogram(w, prefix+h.name, labelReplacer.Replace(name), h.buckets, h.get(m.methods[name]))
+		}
+	}
+}
+
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.Export(w)
+}
@@ -0,0 +1,47 @@
+package tg_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	metrics := &tg.PrometheusMetrics{}
+	m, api := setUpMock("getMe", map[string]interface{}{
+		"ok":     true,
+		"result": commonUser,
+	})
+	api.Metrics = metrics
+	_, err := api.GetMe(&tg.GetMeArgs{})
+	assert.Nil(t, err)
+	m.AssertExpectations(t)
+
+	m, api = setUpMock("sendMessage", map[string]interface{}{
+		"ok":          false,
+		"error_code":  429,
+		"description": "Too Many Requests: retry after 5",
+		"parameters":  map[string]interface{}{"retry_after": 5},
+	})
+	api.Metrics = metrics
+	_, err = api.SendMessage(&tg.SendMessageArgs{ChatID: &tg.ChatID{ID: 123}, Text: "Hello, World!"})
+	assert.Error(t, err)
+	_, err = api.EditMessageText(&tg.EditMessageTextArgs{Text: "Hello, World!"})
+	assert.Error(t, err)
+	metrics.ObserveRetry("sendMessage")
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, body, "# TYPE unibot_requests_total counter\n")
+	assert.Contains(t, body, `unibot_requests_total{method="getMe"} 1`)
+	assert.Contains(t, body, `unibot_requests_total{method="sendMessage"} 1`)
+	assert.Contains(t, body, `unibot_errors_total{method="editMessageText",category="build"} 1`)
+	assert.Contains(t, body, `unibot_errors_total{method="sendMessage",category="flood_wait"} 1`)
+	assert.Contains(t, body, `unibot_retries_total{method="sendMessage"} 1`)
+	assert.Contains(t, body, `unibot_request_duration_seconds_count{method="getMe"} 1`)
+	assert.Contains(t, body, `unibot_response_size_bytes_bucket{method="getMe",le="+Inf"} 1`)
+	assert.Contains(t, body, `unibot_request_size_bytes_bucket{method="getMe",le="1048576"} 1`)
+}
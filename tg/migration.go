@@ -0,0 +1,96 @@
+package tg
+
+import "sync"
+
+// ChatMigration follows upgrades of groups to supergroups. Migrations are
+// detected from the migrate_to_chat_id of errors and from the service messages.
+// Add the Interceptor to API to send to the new chat and add ChatMigration
+// to the update handlers to detect migrations before sending.
+type ChatMigration struct {
+	OnMigrate func(fromChatID int, toChatID int) // Optional, called once for every migration
+	mu        sync.Mutex
+	chats     map[int]int
+}
+
+// Migrate records the migration and calls OnMigrate if it is new.
+func (m *ChatMigration) Migrate(fromChatID int, toChatID int) {
+	m.mu.Lock()
+	if m.chats == nil {
+		m.chats = make(map[int]int)
+	}
+	_, ok := m.chats[fromChatID]
+	m.chats[fromChatID] = toChatID
+	m.mu.Unlock()
+	if !ok && m.OnMigrate != nil {
+		m.OnMigrate(fromChatID, toChatID)
+	}
+}
+
+// ChatID returns the current identifier of the chat.
+func (m *ChatMigration) ChatID(chatID int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if toChatID, ok := m.chats[chatID]; ok {
+		return toChatID
+	}
+	return chatID
+}
+
+// HandleUpdate records migrations from the service messages. It never handles
+// the update, so it can be followed by other handlers.
+func (m *ChatMigration) HandleUpdate(update *Update) (bool, error) {
+	message := update.Message
+	if message == nil || message.Chat == nil {
+		return false, nil
+	}
+	if message.MigrateToChatID != 0 {
+		m.Migrate(message.Chat.ID, message.MigrateToChatID)
+	}
+	if message.MigrateFromChatID != 0 {
+		m.Migrate(message.MigrateFromChatID, message.Chat.ID)
+	}
+	return false, nil
+}
+
+func (m *ChatMigration) redirect(inv *Invocation, toChatID int) error {
+	args, ok := withChatID(inv.Args, &ChatID{ID: toChatID})
+	if !ok {
+		return nil
+	}
+	request, err := inv.api.buildRequestArgs(args)
+	if err != nil {
+		return NewBuildRequestError(err.Error())
+	}
+	inv.Args = args
+	inv.Request = request
+	return nil
+}
+
+// Interceptor sends calls for migrated chats to the new chats. A call failed
+// because of a migration is repeated once with the new chat.
+func (m *ChatMigration) Interceptor() Interceptor {
+	return func(inv *Invocation, next Invoker) error {
+		if chatID := argsChatID(inv.Args); chatID != nil && chatID.ID != 0 {
+			if toChatID := m.ChatID(chatID.ID); toChatID != chatID.ID {
+				if err := m.redirect(inv, toChatID); err != nil {
+					return err
+				}
+			}
+		}
+		err := next(inv)
+		apiErr, ok := err.(*APIError)
+		if !ok || apiErr.Parameters == nil || apiErr.Parameters.MigrateToChatID == 0 {
+			return err
+		}
+		chatID := argsChatID(inv.Args)
+		if chatID == nil || chatID.ID == 0 {
+			return err
+		}
+		m.Migrate(chatID.ID, apiErr.Parameters.MigrateToChatID)
+		if redirectErr := m.redirect(inv, apiErr.Parameters.MigrateToChatID); redirectErr != nil {
+			return redirectErr
+		}
+		inv.api.observeRetry(inv.Method)
+		return next(inv)
+	}
+}
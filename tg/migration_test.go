@@ -0,0 +1,75 @@
+package tg_test
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/websuslik/unibot/tg"
+	"testing"
+)
+
+func TestChatMigration(t *testing.T) {
+	migrated, _ := json.Marshal(map[string]interface{}{
+		"ok":          false,
+		"error_code":  400,
+		"description": "Bad Request: group chat was upgraded to a supergroup chat",
+		"parameters":  map[string]interface{}{"migrate_to_chat_id": -1002},
+	})
+	sent, _ := json.Marshal(map[string]interface{}{"ok": true, "result": commonMessage})
+	var chats []string
+	m := new(HttpClientMock)
+	chatIs := func(chatID string) interface{} {
+		return mock.MatchedBy(func(args *tg.RequestArgs) bool {
+			var params struct {
+				ChatID json.RawMessage `json:"chat_id"`
+			}
+			_ = json.Unmarshal(args.Body.Bytes(), &params)
+			if string(params.ChatID) == chatID {
+				chats = append(chats, chatID)
+				return true
+			}
+			return false
+		})
+	}
+	m.On("Do", mock.Anything, chatIs("-1001"), mock.Anything).Return(migrated, nil)
+	m.On("Do", mock.Anything, chatIs("-1002"), mock.Anything).Return(sent, nil)
+
+	var migrations [][2]int
+	migration := &tg.ChatMigration{OnMigrate: func(from int, to int) {
+		migrations = append(migrations, [2]int{from, to})
+	}}
+	api := &tg.API{Token: "TOKEN", Client: m, Interceptors: []tg.Interceptor{migration.Interceptor()}}
+	args := &tg.SendMessageArgs{ChatID: &tg.ChatID{ID: -1001}, Text: "Hello, World!"}
+
+	res, err := api.SendMessage(args)
+	assert.Nil(t, err)
+	assert.Equal(t, 123, res.MessageID)
+	assert.Equal(t, -1001, args.ChatID.ID)
+	assert.Equal(t, [][2]int{{-1001, -1002}}, migrations)
+
+	// the known migration is followed without the failed call
+	_, err = api.SendMessage(args)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"-1001", "-1002", "-1002"}, chats)
+	assert.Len(t, migrations, 1)
+}
+
+func TestChatMigrationUpdate(t *testing.T) {
+	var migrations [][2]int
+	migration := &tg.ChatMigration{OnMigrate: func(from int, to int) {
+		migrations = append(migrations, [2]int{from, to})
+	}}
+	handled, err := migration.HandleUpdate(&tg.Update{Message: &tg.Message{
+		Chat:            &tg.Chat{ID: -1001, Type: "group"},
+		MigrateToChatID: -1002,
+	}})
+	assert.Nil(t, err)
+	assert.False(t, handled)
+	_, _ = migration.HandleUpdate(&tg.Update{Message: &tg.Message{
+		Chat:              &tg.Chat{ID: -1002, Type: "supergroup"},
+		MigrateFromChatID: -1001,
+	}})
+	assert.Equal(t, [][2]int{{-1001, -1002}}, migrations)
+	assert.Equal(t, -1002, migration.ChatID(-1001))
+	assert.Equal(t, 123, migration.ChatID(123))
+}
@@ -0,0 +1,304 @@
+package tg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+const (
+	outboxCall      = "call"
+	outboxDelivered = "delivered"
+	outboxFailed    = "failed"
+)
+
+// outboxRecord is a line of the outbox write-ahead log.
+type outboxRecord struct {
+	Op         string          `json:"op"`
+	Key        string          `json:"key"`
+	Method     string          `json:"method,omitempty"`
+	Params     json.RawMessage `json:"params,omitempty"`
+	Files      []*InputFile    `json:"files,omitempty"`
+	MessageIDs []int           `json:"message_ids,omitempty"`
+}
+
+// Outbox journals method calls to a write-ahead log before executing them.
+// Calls which are not known to be completed, e.g. because the process died
+// or the request timed out, are executed again by Replay, so every call is
+// delivered at least once. Only calls rejected by Telegram are dropped, calls
+// failed with flood waits or server errors stay pending. Calls to a group
+// upgraded to a supergroup are sent to the new chat.
+// A call is identified by its deduplication key: a call with the key of
+// a delivered call is not executed again.
+type Outbox struct {
+	API       *API
+	path      string
+	mu        sync.Mutex
+	file      *os.File
+	pending   []*outboxRecord
+	delivered map[string][]int
+	inFlight  map[string]bool
+}
+
+// OpenOutbox opens the log at path, creating it if needed, and restores
+// the pending and delivered calls from it.
+func OpenOutbox(api *API, path string) (*Outbox, error) {
+	o := &Outbox{
+		API:       api,
+		path:      path,
+		delivered: make(map[string][]int),
+		inFlight:  make(map[string]bool),
+	}
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	o.file = file
+	return o, nil
+}
+
+func (o *Outbox) load() error {
+	file, err := os.Open(o.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		record := &outboxRecord{}
+		// the last line can be truncated by a crash
+		if err = json.Unmarshal(scanner.Bytes(), record); err == nil {
+			o.apply(record)
+		}
+	}
+	return scanner.Err()
+}
+
+func (o *Outbox) removePending(key string) {
+	for i, record := range o.pending {
+		if record.Key == key {
+			o.pending = append(o.pending[:i], o.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+func (o *Outbox) apply(record *outboxRecord) {
+	o.removePending(record.Key)
+	switch record.Op {
+	case outboxCall:
+		o.pending = append(o.pending, record)
+	case outboxDelivered:
+		o.delivered[record.Key] = record.MessageIDs
+	}
+}
+
+func (o *Outbox) write(record *outboxRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err = o.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err = o.file.Sync(); err != nil {
+		return err
+	}
+	o.apply(record)
+	return nil
+}
+
+// Delivered returns IDs of the messages sent by the call with the key.
+func (o *Outbox) Delivered(key string) ([]int, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	messageIDs, ok := o.delivered[key]
+	return messageIDs, ok
+}
+
+// Pending returns the number of calls which are not known to be completed.
+func (o *Outbox) Pending() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+// messageIDs returns IDs of the messages in a method result, a message or a list of messages.
+func messageIDs(result json.RawMessage) []int {
+	var message struct {
+		MessageID int `json:"message_id"`
+	}
+	if json.Unmarshal(result, &message) == nil && message.MessageID != 0 {
+		return []int{message.MessageID}
+	}
+	var messages []struct {
+		MessageID int `json:"message_id"`
+	}
+	var ids []int
+	if json.Unmarshal(result, &messages) == nil {
+		for _, m := range messages {
+			ids = append(ids, m.MessageID)
+		}
+	}
+	return ids
+}
+
+// migratedRecord returns the call record sent to the new chat of the migrated group.
+func migratedRecord(record *outboxRecord, err error) *outboxRecord {
+	params, ok := migratedParams(record.Params, err)
+	if !ok {
+		return nil
+	}
+	migrated := *record
+	migrated.Params = params
+	return &migrated
+}
+
+func (o *Outbox) execute(record *outboxRecord) ([]int, error) {
+	var result json.RawMessage
+	err := callRaw(o.API, record.Method, record.Params, record.Files, &result)
+	if migrated := migratedRecord(record, err); migrated != nil {
+		// the call is journaled with the new chat, so a replay sends it there too
+		o.mu.Lock()
+		writeErr := o.write(migrated)
+		o.mu.Unlock()
+		if writeErr == nil {
+			o.API.observeRetry(record.Method)
+			record = migrated
+			err = callRaw(o.API, record.Method, record.Params, record.Files, &result)
+		} else {
+			err = writeErr
+		}
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.inFlight, record.Key)
+	if err == nil {
+		ids := messageIDs(result)
+		if writeErr := o.write(&outboxRecord{Op: outboxDelivered, Key: record.Key, MessageIDs: ids}); writeErr != nil {
+			return ids, writeErr
+		}
+		return ids, nil
+	}
+	if callRejected(err) {
+		// the call is not delivered and is not replayed
+		if writeErr := o.write(&outboxRecord{Op: outboxFailed, Key: record.Key}); writeErr != nil {
+			return nil, writeErr
+		}
+	}
+	return nil, err
+}
+
+// Send journals and executes the method of args, e.g. *SendMessageArgs.
+// If a call with the key is delivered already, its message IDs are returned without sending.
+func (o *Outbox) Send(key string, args MethodArgs) ([]int, error) {
+	if key == "" {
+		return nil, errors.New("deduplication key is empty")
+	}
+	method, params, files, err := methodParams(args)
+	if err != nil {
+		return nil, NewBuildRequestError(err.Error())
+	}
+	record := &outboxRecord{Op: outboxCall, Key: key, Method: method, Params: params, Files: files}
+	o.mu.Lock()
+	if ids, ok := o.delivered[key]; ok {
+		o.mu.Unlock()
+		return ids, nil
+	}
+	if o.inFlight[key] {
+		o.mu.Unlock()
+		return nil, errors.New("call " + key + " is in progress")
+	}
+	if err = o.write(record); err == nil {
+		o.inFlight[key] = true
+	}
+	o.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return o.execute(record)
+}
+
+// Replay executes the pending calls in their order, it should be called on start.
+// It stops on the first error which leaves the call pending.
+func (o *Outbox) Replay() error {
+	o.mu.Lock()
+	var pending []*outboxRecord
+	for _, record := range o.pending {
+		if !o.inFlight[record.Key] {
+			o.inFlight[record.Key] = true
+			pending = append(pending, record)
+		}
+	}
+	o.mu.Unlock()
+	for i, record := range pending {
+		if _, err := o.execute(record); err != nil {
+			if callRejected(err) {
+				continue
+			}
+			o.mu.Lock()
+			for _, skipped := range pending[i+1:] {
+				delete(o.inFlight, skipped.Key)
+			}
+			o.mu.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+// Compact rewrites the log keeping only the pending calls and the delivered keys.
+func (o *Outbox) Compact() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for key, ids := range o.delivered {
+		if err := encoder.Encode(&outboxRecord{Op: outboxDelivered, Key: key, MessageIDs: ids}); err != nil {
+			return err
+		}
+	}
+	for _, record := range o.pending {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	tmp := o.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(buf.Bytes()); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, o.path); err != nil {
+		return err
+	}
+	if err = o.file.Close(); err != nil {
+		return err
+	}
+	o.file, err = os.OpenFile(o.path, os.O_APPEND|os.O_WRONLY, 0644)
+	return err
+}
+
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.file.Close()
+}
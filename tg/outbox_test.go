@@ -0,0 +1,114 @@
+package tg_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/websuslik/unibot/tg"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	body, _ := json.Marshal(map[string]interface{}{"ok": true, "result": commonMessage})
+	m := new(HttpClientMock)
+	m.On("Do", mock.Anything, mock.Anything, mock.Anything).Return([]byte(nil), errors.New("timeout")).Once()
+	m.On("Do", mock.Anything, mock.Anything, mock.Anything).Return(body, nil)
+	api := &tg.API{Token: "TOKEN", Client: m}
+	args := &tg.SendMessageArgs{ChatID: &tg.ChatID{ID: 123}, Text: "Hello, World!"}
+
+	outbox, err := tg.OpenOutbox(api, path)
+	assert.Nil(t, err)
+	_, err = outbox.Send("greeting", args)
+	assert.IsType(t, &tg.SendRequestError{}, err)
+	assert.Equal(t, 1, outbox.Pending())
+	assert.Nil(t, outbox.Close())
+
+	// the call is replayed after a restart
+	outbox, err = tg.OpenOutbox(api, path)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, outbox.Pending())
+	assert.Nil(t, outbox.Replay())
+	assert.Equal(t, 0, outbox.Pending())
+	ids, ok := outbox.Delivered("greeting")
+	assert.True(t, ok)
+	assert.Equal(t, []int{123}, ids)
+
+	// a delivered call is not sent again
+	ids, err = outbox.Send("greeting", args)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{123}, ids)
+	m.AssertNumberOfCalls(t, "Do", 2)
+
+	assert.Nil(t, outbox.Compact())
+	assert.Nil(t, outbox.Close())
+	outbox, err = tg.OpenOutbox(api, path)
+	assert.Nil(t, err)
+	ids, ok = outbox.Delivered("greeting")
+	assert.True(t, ok)
+	assert.Equal(t, []int{123}, ids)
+	assert.Nil(t, outbox.Close())
+}
+
+func TestOutboxAPIError(t *testing.T) {
+	m, api := setUpMock("sendMessage", map[string]interface{}{
+		"ok":          false,
+		"error_code":  403,
+		"description": "Forbidden: bot was blocked by the user",
+	})
+	outbox, err := tg.OpenOutbox(api, filepath.Join(t.TempDir(), "outbox.log"))
+	assert.Nil(t, err)
+	defer outbox.Close()
+	_, err = outbox.Send("greeting", &tg.SendMessageArgs{ChatID: &tg.ChatID{ID: 123}, Text: "Hello, World!"})
+	m.AssertExpectations(t)
+	assert.IsType(t, &tg.APIError{}, err)
+	assert.Equal(t, 0, outbox.Pending())
+	_, ok := outbox.Delivered("greeting")
+	assert.False(t, ok)
+}
+
+func TestOutboxFloodWait(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	flood, _ := json.Marshal(errorResponse(429, "Too Many Requests: retry after 5", map[string]interface{}{"retry_after": 5}))
+	body, _ := json.Marshal(map[string]interface{}{"ok": true, "result": commonMessage})
+	m := new(HttpClientMock)
+	m.On("Do", mock.Anything, mock.Anything, mock.Anything).Return(flood, nil).Twice()
+	m.On("Do", mock.Anything, mock.Anything, mock.Anything).Return(body, nil)
+	api := &tg.API{Token: "TOKEN", Client: m}
+
+	outbox, err := tg.OpenOutbox(api, path)
+	assert.Nil(t, err)
+	_, err = outbox.Send("greeting", &tg.SendMessageArgs{ChatID: &tg.ChatID{ID: 123}, Text: "Hello, World!"})
+	assert.IsType(t, &tg.APIError{}, err)
+	assert.Equal(t, 1, outbox.Pending())
+	assert.Nil(t, outbox.Close())
+
+	// the call stays pending until it is delivered
+	outbox, err = tg.OpenOutbox(api, path)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, outbox.Pending())
+	assert.IsType(t, &tg.APIError{}, outbox.Replay())
+	assert.Equal(t, 1, outbox.Pending())
+	assert.Nil(t, outbox.Replay())
+	assert.Equal(t, 0, outbox.Pending())
+	_, ok := outbox.Delivered("greeting")
+	assert.True(t, ok)
+	assert.Nil(t, outbox.Close())
+}
+
+func TestOutboxMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	client := &broadcastClient{responses: map[int]map[string]interface{}{
+		123: errorResponse(400, "Bad Request: group chat was upgraded to a supergroup chat", map[string]interface{}{"migrate_to_chat_id": -100}),
+	}}
+	outbox, err := tg.OpenOutbox(&tg.API{Token: "TOKEN", Client: client}, path)
+	assert.Nil(t, err)
+	defer outbox.Close()
+	ids, err := outbox.Send("greeting", &tg.SendMessageArgs{ChatID: &tg.ChatID{ID: 123}, Text: "Hello, World!"})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{123}, ids)
+	assert.Equal(t, []int{123, -100}, client.sent)
+	assert.Equal(t, 0, outbox.Pending())
+}
@@ -0,0 +1,304 @@
+package tg
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const PassportScopeVersion = 1
+
+const (
+	PassportElementTypePersonalDetails       = "personal_details"
+	PassportElementTypePassport              = "passport"
+	PassportElementTypeDriverLicense         = "driver_license"
+	PassportElementTypeIdentityCard          = "identity_card"
+	PassportElementTypeInternalPassport      = "internal_passport"
+	PassportElementTypeAddress               = "address"
+	PassportElementTypeUtilityBill           = "utility_bill"
+	PassportElementTypeBankStatement         = "bank_statement"
+	PassportElementTypeRentalAgreement       = "rental_agreement"
+	PassportElementTypePassportRegistration  = "passport_registration"
+	PassportElementTypeTemporaryRegistration = "temporary_registration"
+	PassportElementTypePhoneNumber           = "phone_number"
+	PassportElementTypeEmail                 = "email"
+)
+
+// https://core.telegram.org/passport#passportscope
+type PassportScope struct {
+	Data []interface{} `json:"data"` // PassportScopeElementOne or PassportScopeElementOneOfSeveral
+	V    int           `json:"v"`
+}
+
+// https://core.telegram.org/passport#passportscopeelementoneofseveral
+type PassportScopeElementOneOfSeveral struct {
+	OneOf       []*PassportScopeElementOne `json:"one_of"`
+	Selfie      bool                       `json:"selfie,omitempty"`
+	Translation bool                       `json:"translation,omitempty"`
+}
+
+// https://core.telegram.org/passport#passportscopeelementone
+type PassportScopeElementOne struct {
+	Type        string `json:"type"`
+	Selfie      bool   `json:"selfie,omitempty"`
+	Translation bool   `json:"translation,omitempty"`
+	NativeNames bool   `json:"native_names,omitempty"`
+}
+
+// PassportRequest describes a single passport authorization request.
+// Telegram asks the user for every element of the scope, so optional elements
+// only differ in that Missing does not report them.
+type PassportRequest struct {
+	BotID       int
+	PublicKey   string // PEM encoded public key
+	Nonce       string
+	CallbackURL string
+	Scope       *PassportScope
+	required    [][]string
+}
+
+func generatePassportNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+func NewPassportRequest(botID int, publicKey string) (*PassportRequest, error) {
+	nonce, err := generatePassportNonce()
+	if err != nil {
+		return nil, err
+	}
+	return &PassportRequest{
+		BotID:     botID,
+		PublicKey: publicKey,
+		Nonce:     nonce,
+		Scope:     &PassportScope{V: PassportScopeVersion},
+	}, nil
+}
+
+func (r *PassportRequest) Require(elements ...*PassportScopeElementOne) *PassportRequest {
+	for _, element := range elements {
+		r.Scope.Data = append(r.Scope.Data, element)
+		r.required = append(r.required, []string{element.Type})
+	}
+	return r
+}
+
+func (r *PassportRequest) RequireOneOf(group *PassportScopeElementOneOfSeveral) *PassportRequest {
+	r.Scope.Data = append(r.Scope.Data, group)
+	var types []string
+	for _, element := range group.OneOf {
+		types = append(types, element.Type)
+	}
+	r.required = append(r.required, types)
+	return r
+}
+
+func (r *PassportRequest) Optional(elements ...*PassportScopeElementOne) *PassportRequest {
+	for _, element := range elements {
+		r.Scope.Data = append(r.Scope.Data, element)
+	}
+	return r
+}
+
+func (r *PassportRequest) OptionalOneOf(group *PassportScopeElementOneOfSeveral) *PassportRequest {
+	r.Scope.Data = append(r.Scope.Data, group)
+	return r
+}
+
+func (r *PassportRequest) Params() (url.Values, error) {
+	if r.BotID == 0 {
+		return nil, errors.New("passport request: bot id is not set")
+	}
+	if r.PublicKey == "" {
+		return nil, errors.New("passport request: public key is not set")
+	}
+	if r.Nonce == "" {
+		return nil, errors.New("passport request: nonce is not set")
+	}
+	scope, err := json.Marshal(r.Scope)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("domain", "telegrampassport")
+	params.Set("bot_id", strconv.Itoa(r.BotID))
+	params.Set("scope", string(scope))
+	params.Set("public_key", r.PublicKey)
+	params.Set("nonce", r.Nonce)
+	// Older clients read the nonce from the payload parameter
+	params.Set("payload", r.Nonce)
+	if r.CallbackURL != "" {
+		params.Set("callback_url", r.CallbackURL)
+	}
+	return params, nil
+}
+
+// https://core.telegram.org/passport#requesting-information
+func (r *PassportRequest) DeepLink() (string, error) {
+	params, err := r.Params()
+	if err != nil {
+		return "", err
+	}
+	return "tg://resolve?" + params.Encode(), nil
+}
+
+// Missing returns the required element types which are absent in the submitted data.
+// A one_of group is reported as its types joined with "|".
+func (r *PassportRequest) Missing(data *PassportData) []string {
+	submitted := make(map[string]bool)
+	for _, element := range data.Data {
+		submitted[element.Type] = true
+	}
+	var missing []string
+	for _, types := range r.required {
+		found := false
+		for _, elementType := range types {
+			if submitted[elementType] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, strings.Join(types, "|"))
+		}
+	}
+	return missing
+}
+
+// https://core.telegram.org/passport#credentials
+type PassportCredentials struct {
+	SecureData map[string]*SecureValue `json:"secure_data"`
+	Nonce      string                  `json:"nonce"`
+}
+
+// https://core.telegram.org/passport#securevalue
+type SecureValue struct {
+	Data        *DataCredentials   `json:"data,omitempty"`
+	FrontSide   *FileCredentials   `json:"front_side,omitempty"`
+	ReverseSide *FileCredentials   `json:"reverse_side,omitempty"`
+	Selfie      *FileCredentials   `json:"selfie,omitempty"`
+	Translation []*FileCredentials `json:"translation,omitempty"`
+	Files       []*FileCredentials `json:"files,omitempty"`
+}
+
+// https://core.telegram.org/passport#datacredentials
+type DataCredentials struct {
+	DataHash string `json:"data_hash"`
+	Secret   string `json:"secret"`
+}
+
+// https://core.telegram.org/passport#filecredentials
+type FileCredentials struct {
+	FileHash string `json:"file_hash"`
+	Secret   string `json:"secret"`
+}
+
+// https://core.telegram.org/passport#decrypting-data
+func (c *EncryptedCredentials) Decrypt(key *rsa.PrivateKey) (*PassportCredentials, error) {
+	encryptedSecret, err := base64.StdEncoding.DecodeString(c.Secret)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, key, encryptedSecret, nil)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := base64.StdEncoding.DecodeString(c.Hash)
+	if err != nil {
+		return nil, err
+	}
+	encryptedData, err := base64.StdEncoding.DecodeString(c.Data)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decryptPassportData(encryptedData, secret, hash)
+	if err != nil {
+		return nil, err
+	}
+	var credentials *PassportCredentials
+	if err = json.Unmarshal(data, &credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func decryptPassportData(data []byte, secret []byte, hash []byte) ([]byte, error) {
+	secretHash := sha512.Sum512(append(append([]byte{}, secret...), hash...))
+	block, err := aes.NewCipher(secretHash[:32])
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("passport data: invalid encrypted data length")
+	}
+	decrypted := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, secretHash[32:48]).CryptBlocks(decrypted, data)
+	dataHash := sha256.Sum256(decrypted)
+	if !bytes.Equal(dataHash[:], hash) {
+		return nil, errors.New("passport data: hash mismatch")
+	}
+	padding := int(decrypted[0])
+	if padding < 32 || padding > len(decrypted) {
+		return nil, errors.New("passport data: invalid padding")
+	}
+	return decrypted[padding:], nil
+}
+
+// PassportRequests keeps issued requests by nonce so that incoming
+// Message.PassportData can be matched back to the request.
+type PassportRequests struct {
+	PrivateKey *rsa.PrivateKey
+	mu         sync.Mutex
+	requests   map[string]*PassportRequest
+}
+
+func (r *PassportRequests) Add(request *PassportRequest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.requests == nil {
+		r.requests = make(map[string]*PassportRequest)
+	}
+	r.requests[request.Nonce] = request
+}
+
+func (r *PassportRequests) Remove(nonce string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.requests, nonce)
+}
+
+// Match decrypts the credentials and returns the request issued with the same nonce.
+// A matched request is removed, so a nonce can be used only once.
+func (r *PassportRequests) Match(data *PassportData) (*PassportRequest, *PassportCredentials, error) {
+	if data == nil || data.Credentials == nil {
+		return nil, nil, errors.New("passport data: credentials are missing")
+	}
+	credentials, err := data.Credentials.Decrypt(r.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	request, ok := r.requests[credentials.Nonce]
+	if !ok {
+		return nil, nil, errors.New("passport data: unknown nonce")
+	}
+	delete(r.requests, credentials.Nonce)
+	return request, credentials, nil
+}
@@ -0,0 +1,84 @@
+package tg_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func encryptCredentials(t *testing.T, key *rsa.PublicKey, credentials *tg.PassportCredentials) *tg.EncryptedCredentials {
+	data, _ := json.Marshal(credentials)
+	padding := 32 + (16-(len(data)+32)%16)%16
+	padded := make([]byte, padding+len(data))
+	padded[0] = byte(padding)
+	copy(padded[padding:], data)
+	hash := sha256.Sum256(padded)
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	secretHash := sha512.Sum512(append(append([]byte{}, secret...), hash[:]...))
+	block, _ := aes.NewCipher(secretHash[:32])
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, secretHash[32:48]).CryptBlocks(encrypted, padded)
+	encryptedSecret, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, key, secret, nil)
+	assert.Nil(t, err)
+	return &tg.EncryptedCredentials{
+		Data:   base64.StdEncoding.EncodeToString(encrypted),
+		Hash:   base64.StdEncoding.EncodeToString(hash[:]),
+		Secret: base64.StdEncoding.EncodeToString(encryptedSecret),
+	}
+}
+
+func TestPassportRequestDeepLink(t *testing.T) {
+	request, err := tg.NewPassportRequest(123, "PUBLIC KEY")
+	assert.Nil(t, err)
+	request.Require(&tg.PassportScopeElementOne{Type: tg.PassportElementTypePersonalDetails, NativeNames: true}).
+		RequireOneOf(&tg.PassportScopeElementOneOfSeveral{
+			OneOf: []*tg.PassportScopeElementOne{
+				{Type: tg.PassportElementTypePassport},
+				{Type: tg.PassportElementTypeIdentityCard},
+			},
+			Selfie: true,
+		}).
+		Optional(&tg.PassportScopeElementOne{Type: tg.PassportElementTypeEmail})
+	link, err := request.DeepLink()
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(link, "tg://resolve?"))
+	params, _ := url.ParseQuery(strings.TrimPrefix(link, "tg://resolve?"))
+	assert.Equal(t, params.Get("domain"), "telegrampassport")
+	assert.Equal(t, params.Get("bot_id"), "123")
+	assert.Equal(t, params.Get("nonce"), request.Nonce)
+	assert.Equal(t, params.Get("scope"), `{"data":[{"type":"personal_details","native_names":true},`+
+		`{"one_of":[{"type":"passport"},{"type":"identity_card"}],"selfie":true},{"type":"email"}],"v":1}`)
+
+	missing := request.Missing(&tg.PassportData{Data: []*tg.EncryptedPassportElement{{Type: tg.PassportElementTypeIdentityCard}}})
+	assert.Equal(t, missing, []string{tg.PassportElementTypePersonalDetails})
+}
+
+func TestPassportRequestsMatch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	requests := &tg.PassportRequests{PrivateKey: key}
+	request, _ := tg.NewPassportRequest(123, "PUBLIC KEY")
+	requests.Add(request)
+
+	credentials := encryptCredentials(t, &key.PublicKey, &tg.PassportCredentials{Nonce: request.Nonce})
+	data := &tg.PassportData{Credentials: credentials}
+	matched, decrypted, err := requests.Match(data)
+	assert.Nil(t, err)
+	assert.Equal(t, matched, request)
+	assert.Equal(t, decrypted.Nonce, request.Nonce)
+
+	_, _, err = requests.Match(data)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,224 @@
+package tg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Telegram waits for an answer to a pre-checkout query for 10 seconds,
+// the default timeout leaves a margin for the answer request itself.
+const PaymentsTimeout = 8 * time.Second
+
+const PaymentsTimeoutMessage = "The order could not be confirmed in time, please try again."
+
+const payloadSignatureSize = 16
+
+const InvoicePayloadMaxSize = 128 // bytes, the limit of Telegram
+
+type ShippingValidator func(query *ShippingQuery, payload string) ([]*ShippingOption, error)
+
+type PreCheckoutValidator func(query *PreCheckoutQuery, payload string) error
+
+// Order is a successful payment. Payload is the raw invoice payload if the
+// signature is not Verified, e.g. because Secret was changed after the invoice.
+type Order struct {
+	Payload  string
+	Verified bool
+	UserID   int
+	ChatID   int
+	Date     int
+	Payment  *SuccessfulPayment
+}
+
+type OrderLedger interface {
+	RecordPayment(order *Order) error
+}
+
+type MemoryOrderLedger struct {
+	mu     sync.Mutex
+	orders []*Order
+}
+
+func (l *MemoryOrderLedger) RecordPayment(order *Order) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.orders = append(l.orders, order)
+	return nil
+}
+
+func (l *MemoryOrderLedger) Orders() []*Order {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]*Order{}, l.orders...)
+}
+
+// Payments wires invoices, shipping and pre-checkout queries and successful
+// payments together. Invoice payloads are signed with Secret, so queries with
+// forged payloads are declined before validators are called.
+type Payments struct {
+	API                  *API
+	Secret               []byte
+	ShippingValidator    ShippingValidator
+	PreCheckoutValidator PreCheckoutValidator
+	Ledger               OrderLedger
+	Timeout              time.Duration
+	TimeoutMessage       string
+}
+
+func (p *Payments) signature(payload string) string {
+	mac := hmac.New(sha256.New, p.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:payloadSignatureSize])
+}
+
+func (p *Payments) SignPayload(payload string) string {
+	return payload + "." + p.signature(payload)
+}
+
+func (p *Payments) VerifyPayload(signed string) (string, error) {
+	idx := strings.LastIndex(signed, ".")
+	if idx == -1 {
+		return "", errors.New("invoice payload: signature is missing")
+	}
+	payload := signed[:idx]
+	if !hmac.Equal([]byte(signed[idx+1:]), []byte(p.signature(payload))) {
+		return "", errors.New("invoice payload: signature mismatch")
+	}
+	return payload, nil
+}
+
+func (p *Payments) getTimeout() time.Duration {
+	if p.Timeout > 0 {
+		return p.Timeout
+	}
+	return PaymentsTimeout
+}
+
+func (p *Payments) getTimeoutMessage() string {
+	if p.TimeoutMessage != "" {
+		return p.TimeoutMessage
+	}
+	return PaymentsTimeoutMessage
+}
+
+// SendInvoice signs args.Payload and sends the invoice.
+func (p *Payments) SendInvoice(args *SendInvoiceArgs) (*Message, error) {
+	invoiceArgs := *args
+	invoiceArgs.Payload = p.SignPayload(args.Payload)
+	if len(invoiceArgs.Payload) > InvoicePayloadMaxSize {
+		return nil, NewBuildRequestError(fmt.Sprintf("signed invoice payload is %d bytes long, the maximum is %d",
+			len(invoiceArgs.Payload), InvoicePayloadMaxSize))
+	}
+	return p.API.SendInvoice(&invoiceArgs)
+}
+
+// HandleUpdate answers shipping and pre-checkout queries and records successful payments.
+// It returns false if the update is not related to payments.
+func (p *Payments) HandleUpdate(update *Update) (bool, error) {
+	switch {
+	case update.ShippingQuery != nil:
+		return true, p.HandleShippingQuery(update.ShippingQuery)
+	case update.PreCheckoutQuery != nil:
+		return true, p.HandlePreCheckoutQuery(update.PreCheckoutQuery)
+	case update.Message != nil && update.Message.SuccessfulPayment != nil:
+		return true, p.HandleSuccessfulPayment(update.Message)
+	}
+	return false, nil
+}
+
+type shippingResult struct {
+	options []*ShippingOption
+	err     error
+}
+
+func (p *Payments) HandleShippingQuery(query *ShippingQuery) error {
+	args := &AnswerShippingQueryArgs{ShippingQueryID: query.ID}
+	payload, err := p.VerifyPayload(query.InvoicePayload)
+	if err == nil && p.ShippingValidator == nil {
+		err = errors.New("shipping is not available")
+	}
+	if err != nil {
+		args.ErrorMessage = err.Error()
+		_, err = p.API.AnswerShippingQuery(args)
+		return err
+	}
+	result := make(chan *shippingResult, 1)
+	go func() {
+		options, err := p.ShippingValidator(query, payload)
+		result <- &shippingResult{options: options, err: err}
+	}()
+	select {
+	case res := <-result:
+		if res.err != nil {
+			args.ErrorMessage = res.err.Error()
+		} else {
+			args.Ok = true
+			args.ShippingOptions = res.options
+		}
+	case <-time.After(p.getTimeout()):
+		args.ErrorMessage = p.getTimeoutMessage()
+	}
+	_, err = p.API.AnswerShippingQuery(args)
+	return err
+}
+
+func (p *Payments) HandlePreCheckoutQuery(query *PreCheckoutQuery) error {
+	args := &AnswerPreCheckoutQueryArgs{PreCheckoutQueryID: query.ID}
+	payload, err := p.VerifyPayload(query.InvoicePayload)
+	if err != nil {
+		args.ErrorMessage = err.Error()
+		_, err = p.API.AnswerPreCheckoutQuery(args)
+		return err
+	}
+	result := make(chan error, 1)
+	go func() {
+		if p.PreCheckoutValidator == nil {
+			result <- nil
+			return
+		}
+		result <- p.PreCheckoutValidator(query, payload)
+	}()
+	select {
+	case err := <-result:
+		if err != nil {
+			args.ErrorMessage = err.Error()
+		} else {
+			args.Ok = true
+		}
+	case <-time.After(p.getTimeout()):
+		args.ErrorMessage = p.getTimeoutMessage()
+	}
+	_, err = p.API.AnswerPreCheckoutQuery(args)
+	return err
+}
+
+// HandleSuccessfulPayment records the payment in the Ledger. The payment is
+// charged already, so it is recorded even if its payload is not verified.
+func (p *Payments) HandleSuccessfulPayment(message *Message) error {
+	if p.Ledger == nil {
+		return nil
+	}
+	payment := message.SuccessfulPayment
+	order := &Order{
+		Payload: payment.InvoicePayload,
+		Date:    message.Date,
+		Payment: payment,
+	}
+	if payload, err := p.VerifyPayload(payment.InvoicePayload); err == nil {
+		order.Payload = payload
+		order.Verified = true
+	}
+	if message.From != nil {
+		order.UserID = message.From.ID
+	}
+	if message.Chat != nil {
+		order.ChatID = message.Chat.ID
+	}
+	return p.Ledger.RecordPayment(order)
+}
@@ -0,0 +1,177 @@
+package tg_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/websuslik/unibot/tg"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordAnswers returns the API answering every call of the method with true
+// and the params of the calls.
+func recordAnswers(method string) (*tg.API, func() []map[string]interface{}) {
+	mu := sync.Mutex{}
+	var answers []map[string]interface{}
+	m := new(HttpClientMock)
+	m.On("Do", "https://api.telegram.org/botTOKEN/"+method, mock.MatchedBy(func(args *tg.RequestArgs) bool {
+		var answer map[string]interface{}
+		_ = json.Unmarshal(args.Body.Bytes(), &answer)
+		mu.Lock()
+		defer mu.Unlock()
+		answers = append(answers, answer)
+		return true
+	}), mock.Anything).Return([]byte(`{"ok":true,"result":true}`), nil)
+	return &tg.API{Token: "TOKEN", Client: m}, func() []map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]map[string]interface{}{}, answers...)
+	}
+}
+
+func TestPaymentsPayload(t *testing.T) {
+	payments := &tg.Payments{Secret: []byte("secret")}
+	signed := payments.SignPayload("order.42")
+	assert.NotEqual(t, "order.42", signed)
+
+	payload, err := payments.VerifyPayload(signed)
+	assert.Nil(t, err)
+	assert.Equal(t, "order.42", payload)
+
+	_, err = payments.VerifyPayload("order")
+	assert.NotNil(t, err)
+	_, err = payments.VerifyPayload("order.43" + signed[len("order.42"):])
+	assert.NotNil(t, err)
+	_, err = (&tg.Payments{Secret: []byte("other")}).VerifyPayload(signed)
+	assert.NotNil(t, err)
+}
+
+func TestPaymentsShippingQuery(t *testing.T) {
+	api, answers := recordAnswers("answerShippingQuery")
+	var payloads []string
+	payments := &tg.Payments{
+		API:    api,
+		Secret: []byte("secret"),
+		ShippingValidator: func(query *tg.ShippingQuery, payload string) ([]*tg.ShippingOption, error) {
+			payloads = append(payloads, payload)
+			if query.ShippingAddress.CountryCode != "DE" {
+				return nil, errors.New("no delivery to the country")
+			}
+			return []*tg.ShippingOption{{ID: "post", Title: "Post", Prices: []*tg.LabeledPrice{{Label: "Post", Amount: 500}}}}, nil
+		},
+	}
+	signed := payments.SignPayload("order.1")
+
+	handled, err := payments.HandleUpdate(&tg.Update{ShippingQuery: &tg.ShippingQuery{
+		ID:              "1",
+		InvoicePayload:  signed,
+		ShippingAddress: &tg.ShippingAddress{CountryCode: "DE"},
+	}})
+	assert.True(t, handled)
+	assert.Nil(t, err)
+	err = payments.HandleShippingQuery(&tg.ShippingQuery{
+		ID:              "2",
+		InvoicePayload:  signed,
+		ShippingAddress: &tg.ShippingAddress{CountryCode: "FR"},
+	})
+	assert.Nil(t, err)
+	// forged payloads are declined without the validator
+	err = payments.HandleShippingQuery(&tg.ShippingQuery{
+		ID:              "3",
+		InvoicePayload:  "order.2" + signed[len("order.1"):],
+		ShippingAddress: &tg.ShippingAddress{CountryCode: "DE"},
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"order.1", "order.1"}, payloads)
+	result := answers()
+	assert.Len(t, result, 3)
+	assert.Equal(t, true, result[0]["ok"])
+	assert.Equal(t, "post", result[0]["shipping_options"].([]interface{})[0].(map[string]interface{})["id"])
+	assert.Equal(t, false, result[1]["ok"])
+	assert.Equal(t, "no delivery to the country", result[1]["error_message"])
+	assert.Equal(t, false, result[2]["ok"])
+	assert.Equal(t, "invoice payload: signature mismatch", result[2]["error_message"])
+}
+
+func TestPaymentsPreCheckoutQuery(t *testing.T) {
+	api, answers := recordAnswers("answerPreCheckoutQuery")
+	release := make(chan struct{})
+	defer close(release)
+	payments := &tg.Payments{
+		API:     api,
+		Secret:  []byte("secret"),
+		Timeout: 10 * time.Millisecond,
+		PreCheckoutValidator: func(query *tg.PreCheckoutQuery, payload string) error {
+			switch query.ID {
+			case "slow":
+				<-release
+			case "sold":
+				return errors.New("sold out")
+			}
+			return nil
+		},
+	}
+	signed := payments.SignPayload("order.1")
+
+	handled, err := payments.HandleUpdate(&tg.Update{PreCheckoutQuery: &tg.PreCheckoutQuery{ID: "ok", InvoicePayload: signed}})
+	assert.True(t, handled)
+	assert.Nil(t, err)
+	assert.Nil(t, payments.HandlePreCheckoutQuery(&tg.PreCheckoutQuery{ID: "sold", InvoicePayload: signed}))
+	assert.Nil(t, payments.HandlePreCheckoutQuery(&tg.PreCheckoutQuery{ID: "forged", InvoicePayload: "order.1.abc"}))
+	start := time.Now()
+	assert.Nil(t, payments.HandlePreCheckoutQuery(&tg.PreCheckoutQuery{ID: "slow", InvoicePayload: signed}))
+	assert.Less(t, time.Since(start), time.Second)
+
+	result := answers()
+	assert.Len(t, result, 4)
+	assert.Equal(t, "ok", result[0]["pre_checkout_query_id"])
+	assert.Equal(t, true, result[0]["ok"])
+	assert.Equal(t, "sold out", result[1]["error_message"])
+	assert.Equal(t, false, result[1]["ok"])
+	assert.Equal(t, "invoice payload: signature mismatch", result[2]["error_message"])
+	assert.Equal(t, false, result[3]["ok"])
+	assert.Equal(t, tg.PaymentsTimeoutMessage, result[3]["error_message"])
+}
+
+func TestPaymentsSuccessfulPayment(t *testing.T) {
+	ledger := &tg.MemoryOrderLedger{}
+	payments := &tg.Payments{Secret: []byte("secret"), Ledger: ledger}
+	payment := &tg.SuccessfulPayment{Currency: "EUR", TotalAmount: 1000, InvoicePayload: payments.SignPayload("order.1")}
+
+	handled, err := payments.HandleUpdate(&tg.Update{Message: &tg.Message{
+		Date:              100,
+		From:              &tg.User{ID: 1},
+		Chat:              &tg.Chat{ID: 2},
+		SuccessfulPayment: payment,
+	}})
+	assert.True(t, handled)
+	assert.Nil(t, err)
+	// a payment with a payload signed by an old secret is charged already, so it is recorded too
+	unverified := &tg.SuccessfulPayment{InvoicePayload: "order.2.abc"}
+	err = payments.HandleSuccessfulPayment(&tg.Message{Date: 200, SuccessfulPayment: unverified})
+	assert.Nil(t, err)
+	handled, _ = payments.HandleUpdate(&tg.Update{Message: &tg.Message{Text: "hi"}})
+	assert.False(t, handled)
+
+	assert.Equal(t, []*tg.Order{
+		{Payload: "order.1", Verified: true, UserID: 1, ChatID: 2, Date: 100, Payment: payment},
+		{Payload: "order.2.abc", Date: 200, Payment: unverified},
+	}, ledger.Orders())
+}
+
+func TestPaymentsSendInvoice(t *testing.T) {
+	m := new(HttpClientMock)
+	payments := &tg.Payments{API: &tg.API{Token: "TOKEN", Client: m}, Secret: []byte("secret")}
+	_, err := payments.SendInvoice(&tg.SendInvoiceArgs{
+		Currency: "USD",
+		Prices:   []*tg.LabeledPrice{{Label: "Item", Amount: 1000}},
+		Payload:  strings.Repeat("a", tg.InvoicePayloadMaxSize-20),
+	})
+	assert.IsType(t, &tg.BuildRequestError{}, err)
+	m.AssertNotCalled(t, "Do", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,261 @@
+package tg
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PollerTimeout       = 30 // seconds
+	PollerMaxDelay      = time.Minute
+	PollerHandleTimeout = 5 * time.Minute
+)
+
+// Poller receives updates with getUpdates and passes them to the Handler.
+// With an AsyncUpdateHandler, e.g. WorkerPool, Telegram is not told about
+// updates after the oldest update in flight, so they are received again and
+// skipped. When Limit updates are received after a slow update, no new updates
+// are received until it is finished or HandleTimeout passes. After HandleTimeout
+// the update is reported to OnError and considered finished.
+type Poller struct {
+	API            *API
+	Handler        UpdateHandler
+	Timeout        int // Optional, long polling timeout in seconds, PollerTimeout by default
+	Limit          int // Optional
+	AllowedUpdates []string
+	Offset         int                  // identifier of the first update which is not handled yet
+	OnError        func(err error)      // Optional, errors of getUpdates and the Handler
+	OnUpdate       func(update *Update) // Optional, called before the Handler
+	OffsetStore    OffsetStore          // Optional, keeps Offset between restarts
+	HandleTimeout  time.Duration        // Optional, time to handle an update by AsyncUpdateHandler, PollerHandleTimeout by default
+	loaded         bool
+	saved          int
+	mu             sync.Mutex
+	received       int               // identifier of the update after the last passed to the Handler
+	inFlight       map[int]time.Time // updates passed to an AsyncUpdateHandler and not finished yet
+	finished       chan struct{}
+}
+
+// OffsetStore keeps the offset of the Poller. The offset is saved after the
+// received updates are handled, so after a restart only the updates handled
+// while the offset was not saved yet are received again. With an
+// AsyncUpdateHandler, e.g. WorkerPool, the offset does not pass updates
+// which are still queued or being handled.
+type OffsetStore interface {
+	LoadOffset() (int, error)
+	SaveOffset(offset int) error
+}
+
+// FileOffsetStore keeps the offset in the file at Path.
+type FileOffsetStore struct {
+	Path string
+}
+
+func (s *FileOffsetStore) LoadOffset() (int, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+func (s *FileOffsetStore) SaveOffset(offset int) error {
+	// the offset is written to a temporary file first, so a crash never leaves a partial offset
+	tmp := s.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strconv.Itoa(offset)), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
+
+func (p *Poller) getTimeout() int {
+	if p.Timeout > 0 {
+		return p.Timeout
+	}
+	return PollerTimeout
+}
+
+func (p *Poller) reportError(err error) {
+	if p.OnError != nil {
+		p.OnError(err)
+	}
+}
+
+// retryDelay returns the delay after a failed getUpdates call.
+func retryDelay(err error, failures int) time.Duration {
+	if apiErr, ok := err.(*APIError); ok && apiErr.Parameters != nil && apiErr.Parameters.RetryAfter > 0 {
+		return time.Duration(apiErr.Parameters.RetryAfter) * time.Second
+	}
+	delay := time.Second << uint(failures-1)
+	if failures > 7 || delay > PollerMaxDelay {
+		return PollerMaxDelay
+	}
+	return delay
+}
+
+// commit moves Offset to the first update which is not finished yet.
+func (p *Poller) getHandleTimeout() time.Duration {
+	if p.HandleTimeout > 0 {
+		return p.HandleTimeout
+	}
+	return PollerHandleTimeout
+}
+
+func (p *Poller) commit() {
+	p.mu.Lock()
+	if p.received < p.Offset {
+		p.received = p.Offset
+	}
+	p.Offset = p.received
+	var expired []int
+	deadline := time.Now().Add(-p.getHandleTimeout())
+	for id, started := range p.inFlight {
+		if started.Before(deadline) {
+			delete(p.inFlight, id)
+			expired = append(expired, id)
+		} else if id < p.Offset {
+			p.Offset = id
+		}
+	}
+	p.mu.Unlock()
+	sort.Ints(expired)
+	for _, id := range expired {
+		p.reportError(fmt.Errorf("update %d is not handled in %s, it is considered finished", id, p.getHandleTimeout()))
+	}
+}
+
+func (p *Poller) finish(updateID int) {
+	p.mu.Lock()
+	delete(p.inFlight, updateID)
+	p.mu.Unlock()
+	select {
+	case p.finished <- struct{}{}:
+	default:
+	}
+}
+
+// wait waits at most Timeout seconds for Offset to pass the offset.
+func (p *Poller) wait(offset int) {
+	timer := time.NewTimer(time.Duration(p.getTimeout()) * time.Second)
+	defer timer.Stop()
+	for p.Offset <= offset {
+		select {
+		case <-p.finished:
+			p.commit()
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+// handle passes the update to the Handler, to an AsyncUpdateHandler
+// the update is in flight until it is finished.
+func (p *Poller) handle(update *Update) error {
+	async, ok := p.Handler.(AsyncUpdateHandler)
+	if !ok {
+		_, err := p.Handler.HandleUpdate(update)
+		return err
+	}
+	p.mu.Lock()
+	if p.inFlight == nil {
+		p.inFlight = make(map[int]time.Time)
+		p.finished = make(chan struct{}, 1)
+	}
+	p.inFlight[update.UpdateID] = time.Now()
+	p.mu.Unlock()
+	handled, err := async.HandleUpdateAsync(update, func() { p.finish(update.UpdateID) })
+	if !handled || err != nil {
+		p.finish(update.UpdateID)
+	}
+	return err
+}
+
+// Poll makes a single getUpdates call and handles the received updates.
+// The call confirms to Telegram only the updates which are finished, so
+// updates still in flight are received again and skipped. If all of the
+// received updates are in flight, Poll waits for one of them to finish.
+func (p *Poller) Poll() error {
+	if p.OffsetStore != nil && !p.loaded {
+		offset, err := p.OffsetStore.LoadOffset()
+		if err != nil {
+			return err
+		}
+		if offset > p.Offset {
+			p.Offset = offset
+		}
+		p.saved = p.Offset
+		p.loaded = true
+	}
+	p.commit()
+	updates, err := p.API.GetUpdates(&GetUpdatesArgs{
+		Offset:         p.Offset,
+		Limit:          p.Limit,
+		Timeout:        p.getTimeout(),
+		AllowedUpdates: p.AllowedUpdates,
+	})
+	if err != nil {
+		return err
+	}
+	handled := 0
+	for _, update := range updates {
+		p.mu.Lock()
+		skip := update.UpdateID < p.received
+		p.mu.Unlock()
+		if skip {
+			continue
+		}
+		if p.OnUpdate != nil {
+			p.OnUpdate(update)
+		}
+		if err = p.handle(update); err != nil {
+			p.reportError(err)
+		}
+		p.mu.Lock()
+		p.received = update.UpdateID + 1
+		p.mu.Unlock()
+		handled++
+	}
+	offset := p.Offset
+	p.commit()
+	if len(updates) > 0 && handled == 0 {
+		p.wait(offset)
+	}
+	if p.OffsetStore != nil && p.Offset > p.saved {
+		if err = p.OffsetStore.SaveOffset(p.Offset); err != nil {
+			return err
+		}
+		p.saved = p.Offset
+	}
+	return nil
+}
+
+// Run polls until ctx is done. A started getUpdates call is not interrupted,
+// so Run returns at most Timeout seconds after ctx is done.
+func (p *Poller) Run(ctx context.Context) error {
+	failures := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := p.Poll()
+		if err == nil {
+			failures = 0
+			continue
+		}
+		p.reportError(err)
+		failures++
+		if err = sleepContext(ctx, retryDelay(err, failures)); err != nil {
+			return err
+		}
+	}
+}
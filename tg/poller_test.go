@@ -0,0 +1,171 @@
+package tg_test
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/websuslik/unibot/tg"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoller(t *testing.T) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"ok": true,
+		"result": []interface{}{
+			map[string]interface{}{"update_id": 10, "message": commonMessage},
+			map[string]interface{}{"update_id": 11, "message": commonMessage},
+		},
+	})
+	m := new(HttpClientMock)
+	m.On("Do", "https://api.telegram.org/botTOKEN/getUpdates", mock.Anything, mock.Anything).Return(body, nil)
+	var received []int
+	poller := &tg.Poller{
+		API: &tg.API{Token: "TOKEN", Client: m},
+		Handler: tg.UpdateHandlerFunc(func(update *tg.Update) (bool, error) {
+			received = append(received, update.UpdateID)
+			return true, nil
+		}),
+	}
+	assert.Nil(t, poller.Poll())
+	m.AssertExpectations(t)
+	assert.Equal(t, []int{10, 11}, received)
+	assert.Equal(t, 12, poller.Offset)
+}
+
+func TestPollerOffsetStore(t *testing.T) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"ok":     true,
+		"result": []interface{}{map[string]interface{}{"update_id": 20, "message": commonMessage}},
+	})
+	m := new(HttpClientMock)
+	m.On("Do", mock.Anything, mock.MatchedBy(func(args *tg.RequestArgs) bool {
+		return strings.Contains(args.Body.String(), `"offset":15`)
+	}), mock.Anything).Return(body, nil)
+	store := &tg.FileOffsetStore{Path: filepath.Join(t.TempDir(), "offset")}
+	assert.Nil(t, store.SaveOffset(15))
+	poller := &tg.Poller{
+		API:         &tg.API{Token: "TOKEN", Client: m},
+		Handler:     tg.UpdateHandlerFunc(func(update *tg.Update) (bool, error) { return true, nil }),
+		OffsetStore: store,
+	}
+	assert.Nil(t, poller.Poll())
+	m.AssertExpectations(t)
+	offset, err := store.LoadOffset()
+	assert.Nil(t, err)
+	assert.Equal(t, 21, offset)
+}
+
+type updatesClient struct {
+	mu      sync.Mutex
+	ids     []int
+	offsets []int
+	calls   chan int
+}
+
+func (c *updatesClient) Do(url string, args *tg.RequestArgs, timeout time.Duration) ([]byte, error) {
+	var params struct {
+		Offset int `json:"offset"`
+	}
+	if err := json.Unmarshal(args.Body.Bytes(), &params); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offsets = append(c.offsets, params.Offset)
+	c.calls <- params.Offset
+	updates := []interface{}{}
+	for _, id := range c.ids {
+		if id >= params.Offset {
+			// every update is in its own chat, so a worker pool handles them in parallel
+			message := map[string]interface{}{"message_id": id, "date": 0, "chat": map[string]interface{}{"id": id, "type": "private"}}
+			updates = append(updates, map[string]interface{}{"update_id": id, "message": message})
+		}
+	}
+	return json.Marshal(map[string]interface{}{"ok": true, "result": updates})
+}
+
+func TestPollerWorkerPool(t *testing.T) {
+	client := &updatesClient{ids: []int{20, 21}, calls: make(chan int, 2)}
+	store := &tg.FileOffsetStore{Path: filepath.Join(t.TempDir(), "offset")}
+	assert.Nil(t, store.SaveOffset(15))
+	release := make(chan struct{})
+	handled := make(chan int, 2)
+	pool := &tg.WorkerPool{
+		Workers: 2,
+		Handler: tg.UpdateHandlerFunc(func(update *tg.Update) (bool, error) {
+			if update.UpdateID == 20 {
+				<-release
+			}
+			handled <- update.UpdateID
+			return true, nil
+		}),
+	}
+	defer pool.Stop()
+	poller := &tg.Poller{
+		API:         &tg.API{Token: "TOKEN", Client: client},
+		Handler:     pool,
+		OffsetStore: store,
+	}
+	// both updates are queued, but the first one is still being handled
+	assert.Nil(t, poller.Poll())
+	assert.Equal(t, 21, <-handled)
+	offset, err := store.LoadOffset()
+	assert.Nil(t, err)
+	assert.Equal(t, 20, offset)
+
+	// the updates in flight are received again, skipped and waited for
+	polled := make(chan error)
+	go func() { polled <- poller.Poll() }()
+	assert.Equal(t, 15, <-client.calls)
+	assert.Equal(t, 20, <-client.calls)
+	close(release)
+	assert.Nil(t, <-polled)
+	assert.Equal(t, 20, <-handled)
+	offset, err = store.LoadOffset()
+	assert.Nil(t, err)
+	assert.Equal(t, 22, offset)
+	assert.Equal(t, []int{15, 20}, client.offsets)
+}
+
+func TestPollerHandleTimeout(t *testing.T) {
+	client := &updatesClient{ids: []int{20, 21}, calls: make(chan int, 2)}
+	store := &tg.FileOffsetStore{Path: filepath.Join(t.TempDir(), "offset")}
+	assert.Nil(t, store.SaveOffset(15))
+	release := make(chan struct{})
+	pool := &tg.WorkerPool{
+		Workers: 2,
+		Handler: tg.UpdateHandlerFunc(func(update *tg.Update) (bool, error) {
+			if update.UpdateID == 20 {
+				<-release
+			}
+			return true, nil
+		}),
+	}
+	defer pool.Stop()
+	defer close(release)
+	var errs []error
+	poller := &tg.Poller{
+		API:           &tg.API{Token: "TOKEN", Client: client},
+		Handler:       pool,
+		OffsetStore:   store,
+		HandleTimeout: 20 * time.Millisecond,
+		OnError:       func(err error) { errs = append(errs, err) },
+	}
+	assert.Nil(t, poller.Poll())
+	assert.Equal(t, 20, poller.Offset)
+
+	// the stuck update does not stop the poller for longer than HandleTimeout
+	time.Sleep(30 * time.Millisecond)
+	assert.Nil(t, poller.Poll())
+	assert.Equal(t, 22, poller.Offset)
+	assert.Equal(t, []int{15, 22}, client.offsets)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "update 20 is not handled")
+	offset, err := store.LoadOffset()
+	assert.Nil(t, err)
+	assert.Equal(t, 22, offset)
+}
@@ -0,0 +1,129 @@
+package tg
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+const (
+	PoolWorkers   = 8
+	PoolQueueSize = 100
+)
+
+var ErrPoolStopped = errors.New("worker pool is stopped")
+
+// updateShardKey returns the key of the update ordering: the chat, or the user
+// for updates without a chat, e.g. inline queries.
+func updateShardKey(update *Update) uint64 {
+	if chat := update.EffectiveChat(); chat != nil {
+		return uint64(chat.ID)
+	}
+	if user := update.EffectiveUser(); user != nil {
+		return uint64(user.ID)
+	}
+	if update.Poll != nil {
+		hash := fnv.New64a()
+		hash.Write([]byte(update.Poll.ID))
+		return hash.Sum64()
+	}
+	return 0
+}
+
+type poolItem struct {
+	update *Update
+	done   func()
+}
+
+// WorkerPool processes updates by several workers. Updates of a chat are
+// processed by the same worker in their order, so a chat never sees its
+// updates reordered while different chats are processed in parallel.
+// HandleUpdate blocks while the queue of the worker is full, so the pool
+// can be used as the handler of Poller or WebhookHandler to slow them down.
+// Poller saves the offset of updates only after the pool handled them, so a
+// slow update stops Poller from receiving new updates, see Poller.HandleTimeout.
+type WorkerPool struct {
+	Handler   UpdateHandler
+	Workers   int                             // Optional, PoolWorkers by default
+	QueueSize int                             // Optional, updates queued per worker, PoolQueueSize by default
+	OnError   func(update *Update, err error) // Optional
+	once      sync.Once
+	mu        sync.RWMutex
+	stopped   bool
+	queues    []chan *poolItem
+	wg        sync.WaitGroup
+}
+
+func (p *WorkerPool) start() {
+	workers := p.Workers
+	if workers <= 0 {
+		workers = PoolWorkers
+	}
+	size := p.QueueSize
+	if size <= 0 {
+		size = PoolQueueSize
+	}
+	p.queues = make([]chan *poolItem, workers)
+	for i := range p.queues {
+		p.queues[i] = make(chan *poolItem, size)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+}
+
+func (p *WorkerPool) work(queue chan *poolItem) {
+	defer p.wg.Done()
+	for item := range queue {
+		p.handle(item)
+	}
+}
+
+// handle recovers panics of the Handler, so a panic does not stop the
+// process, and finishes the update in any case.
+func (p *WorkerPool) handle(item *poolItem) {
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+		if err != nil && p.OnError != nil {
+			p.OnError(item.update, err)
+		}
+		if item.done != nil {
+			item.done()
+		}
+	}()
+	_, err = p.Handler.HandleUpdate(item.update)
+}
+
+// HandleUpdate queues the update, it always handles the update unless the pool is stopped.
+func (p *WorkerPool) HandleUpdate(update *Update) (bool, error) {
+	return p.HandleUpdateAsync(update, nil)
+}
+
+// HandleUpdateAsync queues the update, done is called by the worker after the Handler.
+func (p *WorkerPool) HandleUpdateAsync(update *Update, done func()) (bool, error) {
+	p.once.Do(p.start)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		return false, ErrPoolStopped
+	}
+	p.queues[updateShardKey(update)%uint64(len(p.queues))] <- &poolItem{update: update, done: done}
+	return true, nil
+}
+
+// Stop processes the queued updates and stops the workers.
+func (p *WorkerPool) Stop() {
+	p.once.Do(p.start)
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		for _, queue := range p.queues {
+			close(queue)
+		}
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
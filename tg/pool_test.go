@@ -0,0 +1,63 @@
+package tg_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	mu := sync.Mutex{}
+	received := map[int][]int{}
+	pool := &tg.WorkerPool{
+		Workers:   4,
+		QueueSize: 1,
+		Handler: tg.UpdateHandlerFunc(func(update *tg.Update) (bool, error) {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			chatID := update.Message.Chat.ID
+			received[chatID] = append(received[chatID], update.UpdateID)
+			return true, nil
+		}),
+	}
+	for i := 0; i < 100; i++ {
+		update := &tg.Update{UpdateID: i, Message: &tg.Message{Chat: &tg.Chat{ID: i % 7}}}
+		handled, err := pool.HandleUpdate(update)
+		assert.True(t, handled)
+		assert.Nil(t, err)
+	}
+	pool.Stop()
+	for chatID, updates := range received {
+		for i := 1; i < len(updates); i++ {
+			assert.Less(t, updates[i-1], updates[i], chatID)
+		}
+	}
+	total := 0
+	for _, updates := range received {
+		total += len(updates)
+	}
+	assert.Equal(t, 100, total)
+
+	_, err := pool.HandleUpdate(&tg.Update{})
+	assert.Equal(t, tg.ErrPoolStopped, err)
+}
+
+func TestWorkerPoolPanic(t *testing.T) {
+	errs := make(chan error, 1)
+	pool := &tg.WorkerPool{
+		Handler: tg.UpdateHandlerFunc(func(update *tg.Update) (bool, error) {
+			panic("boom")
+		}),
+		OnError: func(update *tg.Update, err error) { errs <- err },
+	}
+	done := make(chan struct{})
+	handled, err := pool.HandleUpdateAsync(&tg.Update{UpdateID: 1}, func() { close(done) })
+	assert.True(t, handled)
+	assert.Nil(t, err)
+	<-done
+	assert.EqualError(t, <-errs, "handler panic: boom")
+	pool.Stop()
+}
@@ -0,0 +1,67 @@
+package tg
+
+import (
+	"errors"
+	"strconv"
+)
+
+type ChatID struct {
+	ID       int
+	Username string
+}
+
+func (cid *ChatID) MarshalJSON() ([]byte, error) {
+	var value string
+	if cid.ID != 0 {
+		value = strconv.Itoa(cid.ID)
+	} else {
+		value = cid.Username
+	}
+	return []byte(value), nil
+}
+
+// MessageRef points to a message sent by the bot either to a chat or via inline mode.
+// Edit methods return the edited Message for chat messages and nil for inline messages.
+type MessageRef struct {
+	ChatID          *ChatID `json:"chat_id,omitempty"`
+	MessageID       int     `json:"message_id,omitempty"`
+	InlineMessageID string  `json:"inline_message_id,omitempty"`
+}
+
+func NewMessageRef(message *Message) *MessageRef {
+	return &MessageRef{
+		ChatID:    &ChatID{ID: message.Chat.ID},
+		MessageID: message.MessageID,
+	}
+}
+
+func NewInlineMessageRef(inlineMessageID string) *MessageRef {
+	return &MessageRef{InlineMessageID: inlineMessageID}
+}
+
+func NewCallbackQueryMessageRef(query *CallbackQuery) *MessageRef {
+	if query.Message != nil {
+		return NewMessageRef(query.Message)
+	}
+	return NewInlineMessageRef(query.InlineMessageID)
+}
+
+func (r *MessageRef) IsInline() bool {
+	return r.InlineMessageID != ""
+}
+
+func (r *MessageRef) validate() error {
+	if r == nil {
+		return errors.New("message reference is not set")
+	}
+	if r.IsInline() {
+		if r.ChatID != nil || r.MessageID != 0 {
+			return errors.New("message reference must have either inline message id or chat and message ids")
+		}
+		return nil
+	}
+	if r.ChatID == nil || r.MessageID == 0 {
+		return errors.New("message reference must have both chat and message ids")
+	}
+	return nil
+}
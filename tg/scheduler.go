@@ -0,0 +1,397 @@
+package tg
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ScheduledJob is a method call executed at Due. Jobs with Cron are executed
+// repeatedly, Due is the time of the next execution.
+type ScheduledJob struct {
+	ID       string          `json:"id"`
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params"`
+	Files    []*InputFile    `json:"files,omitempty"`
+	Cron     string          `json:"cron,omitempty"`
+	Due      time.Time       `json:"due"`
+	Failures int             `json:"failures,omitempty"` // failed attempts of the current execution
+}
+
+type ScheduleStore interface {
+	Jobs() ([]*ScheduledJob, error)
+	Save(job *ScheduledJob) error
+	Delete(id string) error
+}
+
+type MemoryScheduleStore struct {
+	mu   sync.Mutex
+	jobs map[string]ScheduledJob
+}
+
+func (s *MemoryScheduleStore) Jobs() ([]*ScheduledJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]*ScheduledJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		job := job
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+func (s *MemoryScheduleStore) Save(job *ScheduledJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jobs == nil {
+		s.jobs = make(map[string]ScheduledJob)
+	}
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func (s *MemoryScheduleStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+// FileScheduleStore keeps every job in the <Dir>/<job ID>.job file.
+type FileScheduleStore struct {
+	Dir string
+}
+
+func (s *FileScheduleStore) fileName(id string) string {
+	return filepath.Join(s.Dir, id+".job")
+}
+
+func (s *FileScheduleStore) Jobs() ([]*ScheduledJob, error) {
+	fileNames, err := filepath.Glob(filepath.Join(s.Dir, "*.job"))
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*ScheduledJob, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		job := &ScheduledJob{}
+		if err = json.Unmarshal(data, job); err != nil {
+			return nil, fmt.Errorf("%s: %v", fileName, err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (s *FileScheduleStore) Save(job *ScheduledJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	// the job is written to a temporary file first, so a crash never leaves a partial job
+	tmp := s.fileName(job.ID) + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.fileName(job.ID))
+}
+
+func (s *FileScheduleStore) Delete(id string) error {
+	err := os.Remove(s.fileName(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func generateJobID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// Scheduler executes jobs at their due time. Jobs are kept in the Store, so
+// they survive restarts: one-off jobs missed while the scheduler was stopped
+// are executed on start, recurring jobs continue from the next matching time.
+// A job is removed from the Store after its execution, a crash in between
+// executes the job again. A job failed with a flood wait, a server or
+// a network error is retried with a backoff, see PollerMaxDelay.
+type Scheduler struct {
+	API      *API
+	Store    ScheduleStore                      // Optional, MemoryScheduleStore is used by default
+	Location *time.Location                     // Optional, location of cron recurrences, time.Local by default
+	OnError  func(job *ScheduledJob, err error) // Optional
+	mu       sync.Mutex
+	jobs     map[string]*ScheduledJob
+	wake     chan struct{}
+}
+
+// init loads the jobs from the Store on the first use of the scheduler.
+func (s *Scheduler) init() error {
+	if s.jobs != nil {
+		return nil
+	}
+	if s.wake == nil {
+		s.wake = make(chan struct{}, 1)
+	}
+	if s.Store == nil {
+		s.Store = &MemoryScheduleStore{}
+	}
+	stored, err := s.Store.Jobs()
+	if err != nil {
+		return err
+	}
+	jobs := make(map[string]*ScheduledJob, len(stored))
+	now := time.Now()
+	for _, job := range stored {
+		if job.Cron != "" && job.Due.Before(now) {
+			cron, err := ParseCron(job.Cron)
+			if err != nil {
+				return err
+			}
+			job.Due = cron.Next(now.In(s.getLocation()))
+		}
+		jobs[job.ID] = job
+	}
+	s.jobs = jobs
+	return nil
+}
+
+func (s *Scheduler) getLocation() *time.Location {
+	if s.Location != nil {
+		return s.Location
+	}
+	return time.Local
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) newJob(args MethodArgs) (*ScheduledJob, error) {
+	method, params, files, err := methodParams(args)
+	if err != nil {
+		return nil, err
+	}
+	id, err := generateJobID()
+	if err != nil {
+		return nil, err
+	}
+	return &ScheduledJob{ID: id, Method: method, Params: params, Files: files}, nil
+}
+
+func (s *Scheduler) add(job *ScheduledJob) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return "", err
+	}
+	if err := s.Store.Save(job); err != nil {
+		return "", err
+	}
+	s.jobs[job.ID] = job
+	s.notify()
+	return job.ID, nil
+}
+
+// Schedule executes the method of args, e.g. *SendMessageArgs, at the time.
+func (s *Scheduler) Schedule(args MethodArgs, at time.Time) (string, error) {
+	job, err := s.newJob(args)
+	if err != nil {
+		return "", err
+	}
+	job.Due = at
+	return s.add(job)
+}
+
+// ScheduleCron executes the method of args repeatedly, see Cron for the format of spec.
+func (s *Scheduler) ScheduleCron(args MethodArgs, spec string) (string, error) {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return "", err
+	}
+	job, err := s.newJob(args)
+	if err != nil {
+		return "", err
+	}
+	job.Cron = spec
+	if job.Due = cron.Next(time.Now().In(s.getLocation())); job.Due.IsZero() {
+		return "", errors.New("cron: no matching time")
+	}
+	return s.add(job)
+}
+
+func (s *Scheduler) Cancel(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return err
+	}
+	if _, ok := s.jobs[id]; !ok {
+		return fmt.Errorf("job %s is not found", id)
+	}
+	if err := s.Store.Delete(id); err != nil {
+		return err
+	}
+	delete(s.jobs, id)
+	s.notify()
+	return nil
+}
+
+// Reschedule changes the time of the next execution of the job.
+func (s *Scheduler) Reschedule(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return err
+	}
+	job, ok := s.jobs[id]
+	if !ok {
+		return fmt.Errorf("job %s is not found", id)
+	}
+	updated := *job
+	updated.Due = at
+	if err := s.Store.Save(&updated); err != nil {
+		return err
+	}
+	s.jobs[id] = &updated
+	s.notify()
+	return nil
+}
+
+// Jobs returns the scheduled jobs, it is empty if they can not be loaded from the Store.
+func (s *Scheduler) Jobs() []*ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.init()
+	jobs := make([]*ScheduledJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func (s *Scheduler) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.init()
+}
+
+// next returns the due jobs and the time of the earliest job which is not due yet.
+func (s *Scheduler) next(now time.Time) ([]*ScheduledJob, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*ScheduledJob
+	var next time.Time
+	for _, job := range s.jobs {
+		if !job.Due.After(now) {
+			due = append(due, job)
+		} else if next.IsZero() || job.Due.Before(next) {
+			next = job.Due
+		}
+	}
+	return due, next
+}
+
+// done removes the executed job or schedules its next execution.
+func (s *Scheduler) done(job *ScheduledJob, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.jobs[job.ID]; !ok || current != job {
+		// the job is cancelled or rescheduled during the execution
+		return nil
+	}
+	if job.Cron != "" {
+		cron, err := ParseCron(job.Cron)
+		if err != nil {
+			return err
+		}
+		if next := cron.Next(now.In(s.getLocation())); !next.IsZero() {
+			updated := *job
+			updated.Due = next
+			updated.Failures = 0
+			s.jobs[job.ID] = &updated
+			return s.Store.Save(&updated)
+		}
+	}
+	delete(s.jobs, job.ID)
+	return s.Store.Delete(job.ID)
+}
+
+// retry schedules the job failed with a temporary error once again: after the
+// delay of a flood wait or a backoff, at once for a migrated group.
+func (s *Scheduler) retry(job *ScheduledJob, err error, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.jobs[job.ID]; !ok || current != job {
+		return nil
+	}
+	updated := *job
+	if params, ok := migratedParams(job.Params, err); ok {
+		updated.Params = params
+		updated.Due = now
+	} else {
+		updated.Failures++
+		updated.Due = now.Add(retryDelay(err, updated.Failures))
+	}
+	s.jobs[job.ID] = &updated
+	return s.Store.Save(&updated)
+}
+
+// Run loads the jobs from the Store and executes them until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	for {
+		now := time.Now()
+		due, next := s.next(now)
+		for _, job := range due {
+			err := callRaw(s.API, job.Method, job.Params, job.Files, nil)
+			var doneErr error
+			if err != nil && !callRejected(err) {
+				doneErr = s.retry(job, err, now)
+			} else {
+				doneErr = s.done(job, now)
+			}
+			if err == nil {
+				err = doneErr
+			}
+			if err != nil && s.OnError != nil {
+				s.OnError(job, err)
+			}
+		}
+		if len(due) > 0 {
+			continue
+		}
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = next.Sub(now)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-s.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
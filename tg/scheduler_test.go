@@ -0,0 +1,121 @@
+package tg_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/websuslik/unibot/tg"
+	"testing"
+	"time"
+)
+
+func TestCron(t *testing.T) {
+	tests := []struct {
+		spec string
+		from string
+		next string
+	}{
+		{spec: "* * * * *", from: "2020-01-01T10:00:30Z", next: "2020-01-01T10:01:00Z"},
+		{spec: "*/15 9-17 * * *", from: "2020-01-01T17:50:00Z", next: "2020-01-02T09:00:00Z"},
+		{spec: "0 10 * * 1", from: "2020-01-01T00:00:00Z", next: "2020-01-06T10:00:00Z"},
+		{spec: "30 8 1,15 * *", from: "2020-01-15T09:00:00Z", next: "2020-02-01T08:30:00Z"},
+		{spec: "0 0 29 2 *", from: "2020-03-01T00:00:00Z", next: "2024-02-29T00:00:00Z"},
+		{spec: "0 12 * * 7", from: "2020-01-01T00:00:00Z", next: "2020-01-05T12:00:00Z"},
+	}
+	for _, test := range tests {
+		cron, err := tg.ParseCron(test.spec)
+		assert.Nil(t, err)
+		from, _ := time.Parse(time.RFC3339, test.from)
+		next, _ := time.Parse(time.RFC3339, test.next)
+		assert.Equal(t, next, cron.Next(from), test.spec)
+	}
+	for _, spec := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *"} {
+		_, err := tg.ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	m, api := setUpMock("sendMessage", map[string]interface{}{
+		"ok":     true,
+		"result": commonMessage,
+	})
+	store := &tg.FileScheduleStore{Dir: t.TempDir()}
+	scheduler := &tg.Scheduler{API: api, Store: store}
+	args := &tg.SendMessageArgs{ChatID: &tg.ChatID{ID: 123}, Text: "Hello, World!"}
+
+	id, err := scheduler.Schedule(args, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	cancelled, err := scheduler.Schedule(args, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	recurring, err := scheduler.ScheduleCron(args, "0 9 * * 1-5")
+	assert.Nil(t, err)
+	assert.Nil(t, scheduler.Cancel(cancelled))
+	assert.Error(t, scheduler.Cancel(cancelled))
+
+	// a new scheduler loads the jobs from the store before it runs
+	scheduler = &tg.Scheduler{API: api, Store: store}
+	assert.Len(t, scheduler.Jobs(), 2)
+	assert.Nil(t, scheduler.Reschedule(id, time.Now()))
+	other, err := scheduler.Schedule(args, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Nil(t, (&tg.Scheduler{API: api, Store: store}).Cancel(other))
+
+	scheduler = &tg.Scheduler{API: api, Store: store}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() { stopped <- scheduler.Run(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for len(scheduler.Jobs()) != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	assert.Equal(t, context.Canceled, <-stopped)
+	m.AssertNumberOfCalls(t, "Do", 1)
+	jobs, err := store.Jobs()
+	assert.Nil(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, recurring, jobs[0].ID)
+	assert.Equal(t, "sendMessage", jobs[0].Method)
+	assert.JSONEq(t, `{"chat_id":123,"text":"Hello, World!"}`, string(jobs[0].Params))
+}
+
+func TestSchedulerRetry(t *testing.T) {
+	flood, _ := json.Marshal(errorResponse(429, "Too Many Requests: retry after 1", map[string]interface{}{"retry_after": 1}))
+	sent, _ := json.Marshal(map[string]interface{}{"ok": true, "result": commonMessage})
+	m := new(HttpClientMock)
+	m.On("Do", mock.Anything, mock.Anything, mock.Anything).Return(flood, nil).Once()
+	m.On("Do", mock.Anything, mock.Anything, mock.Anything).Return(sent, nil)
+	store := &tg.FileScheduleStore{Dir: t.TempDir()}
+	failed := make(chan error, 1)
+	scheduler := &tg.Scheduler{
+		API:     &tg.API{Token: "TOKEN", Client: m},
+		Store:   store,
+		OnError: func(job *tg.ScheduledJob, err error) { failed <- err },
+	}
+	_, err := scheduler.Schedule(&tg.SendMessageArgs{ChatID: &tg.ChatID{ID: 123}, Text: "Hello, World!"}, time.Now())
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() { stopped <- scheduler.Run(ctx) }()
+	assert.IsType(t, &tg.APIError{}, <-failed)
+	// the job is kept in the store until it is delivered
+	jobs, err := store.Jobs()
+	assert.Nil(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, 1, jobs[0].Failures)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(scheduler.Jobs()) != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	assert.Equal(t, context.Canceled, <-stopped)
+	m.AssertNumberOfCalls(t, "Do", 2)
+	jobs, err = store.Jobs()
+	assert.Nil(t, err)
+	assert.Len(t, jobs, 0)
+}
@@ -0,0 +1,152 @@
+package tg
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const ShippingDefaultError = "Sorry, we can not deliver to this address."
+
+// Cart maps item identifiers to their quantities.
+type Cart map[string]int
+
+func (c Cart) Quantity() int {
+	quantity := 0
+	for _, q := range c {
+		quantity += q
+	}
+	return quantity
+}
+
+// ShippingRule matches a shipping address and cart. Empty conditions match anything.
+// A matched rule either declines the query with Error or offers Options.
+type ShippingRule struct {
+	Countries    []string // ISO 3166-1 alpha-2 country codes
+	States       []string
+	PostCodes    []*regexp.Regexp
+	RequireItems []string // all of the items must be in the cart
+	ExcludeItems []string // none of the items must be in the cart
+	MinQuantity  int
+	MaxQuantity  int
+	Options      []*ShippingOption
+	Error        string
+	Final        bool // stop evaluating the following rules if matched
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ShippingRule) Match(address *ShippingAddress, cart Cart) bool {
+	if address == nil {
+		address = &ShippingAddress{}
+	}
+	if len(r.Countries) > 0 && !containsFold(r.Countries, address.CountryCode) {
+		return false
+	}
+	if len(r.States) > 0 && !containsFold(r.States, address.State) {
+		return false
+	}
+	if len(r.PostCodes) > 0 {
+		matched := false
+		for _, pattern := range r.PostCodes {
+			if pattern.MatchString(address.PostCode) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, item := range r.RequireItems {
+		if cart[item] <= 0 {
+			return false
+		}
+	}
+	for _, item := range r.ExcludeItems {
+		if cart[item] > 0 {
+			return false
+		}
+	}
+	quantity := cart.Quantity()
+	if r.MinQuantity > 0 && quantity < r.MinQuantity {
+		return false
+	}
+	if r.MaxQuantity > 0 && quantity > r.MaxQuantity {
+		return false
+	}
+	return true
+}
+
+// ShippingRules computes shipping options from the rules in their order.
+// Options of all matched rules are offered, an option ID is offered only once.
+type ShippingRules struct {
+	Rules        []*ShippingRule
+	DecodeCart   func(payload string) (Cart, error) // Optional, cart contents from the invoice payload
+	DefaultError string
+	Currency     string // Optional, used to check option totals when answering
+}
+
+func (r *ShippingRules) getDefaultError() error {
+	if r.DefaultError != "" {
+		return errors.New(r.DefaultError)
+	}
+	return errors.New(ShippingDefaultError)
+}
+
+// Options can be used as ShippingValidator of Payments.
+func (r *ShippingRules) Options(query *ShippingQuery, payload string) ([]*ShippingOption, error) {
+	cart := Cart{}
+	if r.DecodeCart != nil {
+		var err error
+		if cart, err = r.DecodeCart(payload); err != nil {
+			return nil, err
+		}
+	}
+	var options []*ShippingOption
+	seen := make(map[string]bool)
+	for _, rule := range r.Rules {
+		if !rule.Match(query.ShippingAddress, cart) {
+			continue
+		}
+		if rule.Error != "" {
+			return nil, errors.New(rule.Error)
+		}
+		for _, option := range rule.Options {
+			if !seen[option.ID] {
+				seen[option.ID] = true
+				options = append(options, option)
+			}
+		}
+		if rule.Final {
+			break
+		}
+	}
+	if len(options) == 0 {
+		return nil, r.getDefaultError()
+	}
+	return options, nil
+}
+
+// Answer computes options for the query using its raw invoice payload and answers it.
+func (r *ShippingRules) Answer(api *API, query *ShippingQuery) (bool, error) {
+	args := &AnswerShippingQueryArgs{
+		ShippingQueryID: query.ID,
+		Currency:        r.Currency,
+	}
+	options, err := r.Options(query, query.InvoicePayload)
+	if err != nil {
+		args.ErrorMessage = err.Error()
+	} else {
+		args.Ok = true
+		args.ShippingOptions = options
+	}
+	return api.AnswerShippingQuery(args)
+}
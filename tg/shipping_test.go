@@ -0,0 +1,117 @@
+package tg_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"regexp"
+	"testing"
+)
+
+func TestShippingRuleMatch(t *testing.T) {
+	berlin := &tg.ShippingAddress{CountryCode: "DE", State: "Berlin", PostCode: "10115"}
+	tests := []struct {
+		rule    *tg.ShippingRule
+		address *tg.ShippingAddress
+		cart    tg.Cart
+		match   bool
+	}{
+		{rule: &tg.ShippingRule{}, address: nil, match: true},
+		{rule: &tg.ShippingRule{Countries: []string{"at", "de"}}, address: berlin, match: true},
+		{rule: &tg.ShippingRule{Countries: []string{"FR"}}, address: berlin, match: false},
+		{rule: &tg.ShippingRule{Countries: []string{"DE"}}, address: nil, match: false},
+		{rule: &tg.ShippingRule{States: []string{"BERLIN"}}, address: berlin, match: true},
+		{rule: &tg.ShippingRule{States: []string{"Bavaria"}}, address: berlin, match: false},
+		{rule: &tg.ShippingRule{PostCodes: []*regexp.Regexp{regexp.MustCompile(`^8`), regexp.MustCompile(`^1\d{4}$`)}}, address: berlin, match: true},
+		{rule: &tg.ShippingRule{PostCodes: []*regexp.Regexp{regexp.MustCompile(`^8`)}}, address: berlin, match: false},
+		{rule: &tg.ShippingRule{RequireItems: []string{"tea", "cup"}}, cart: tg.Cart{"tea": 1, "cup": 2}, match: true},
+		{rule: &tg.ShippingRule{RequireItems: []string{"tea", "cup"}}, cart: tg.Cart{"tea": 1, "cup": 0}, match: false},
+		{rule: &tg.ShippingRule{ExcludeItems: []string{"glass"}}, cart: tg.Cart{"tea": 1}, match: true},
+		{rule: &tg.ShippingRule{ExcludeItems: []string{"glass"}}, cart: tg.Cart{"tea": 1, "glass": 1}, match: false},
+		{rule: &tg.ShippingRule{MinQuantity: 3}, cart: tg.Cart{"tea": 1, "cup": 2}, match: true},
+		{rule: &tg.ShippingRule{MinQuantity: 4}, cart: tg.Cart{"tea": 1, "cup": 2}, match: false},
+		{rule: &tg.ShippingRule{MaxQuantity: 3}, cart: tg.Cart{"tea": 1, "cup": 2}, match: true},
+		{rule: &tg.ShippingRule{MaxQuantity: 2}, cart: tg.Cart{"tea": 1, "cup": 2}, match: false},
+	}
+	for i, test := range tests {
+		assert.Equal(t, test.match, test.rule.Match(test.address, test.cart), i)
+	}
+}
+
+func TestShippingRulesOptions(t *testing.T) {
+	post := &tg.ShippingOption{ID: "post", Title: "Post", Prices: []*tg.LabeledPrice{{Label: "Post", Amount: 500}}}
+	express := &tg.ShippingOption{ID: "express", Title: "Express", Prices: []*tg.LabeledPrice{{Label: "Express", Amount: 1500}}}
+	pickup := &tg.ShippingOption{ID: "pickup", Title: "Pickup", Prices: []*tg.LabeledPrice{{Label: "Pickup", Amount: 0}}}
+	rules := &tg.ShippingRules{
+		Rules: []*tg.ShippingRule{
+			{ExcludeItems: []string{"glass"}, Countries: []string{"RU"}, Error: "No delivery to Russia."},
+			{Countries: []string{"DE"}, States: []string{"Berlin"}, Options: []*tg.ShippingOption{pickup}, Final: true},
+			{Countries: []string{"DE", "AT"}, Options: []*tg.ShippingOption{post, express}},
+			{MaxQuantity: 2, Options: []*tg.ShippingOption{express, post}},
+		},
+		DecodeCart: func(payload string) (tg.Cart, error) {
+			if payload == "" {
+				return nil, errors.New("cart is empty")
+			}
+			return tg.Cart{payload: 1}, nil
+		},
+	}
+	options := func(countryCode string, state string, payload string) ([]*tg.ShippingOption, error) {
+		return rules.Options(&tg.ShippingQuery{ShippingAddress: &tg.ShippingAddress{CountryCode: countryCode, State: state}}, payload)
+	}
+
+	result, err := options("DE", "Berlin", "tea")
+	assert.Nil(t, err)
+	assert.Equal(t, []*tg.ShippingOption{pickup}, result)
+
+	result, err = options("AT", "", "tea")
+	assert.Nil(t, err)
+	assert.Equal(t, []*tg.ShippingOption{post, express}, result)
+
+	result, err = options("FR", "", "tea")
+	assert.Nil(t, err)
+	assert.Equal(t, []*tg.ShippingOption{express, post}, result)
+
+	_, err = options("RU", "", "tea")
+	assert.EqualError(t, err, "No delivery to Russia.")
+	_, err = options("", "", "")
+	assert.EqualError(t, err, "cart is empty")
+
+	rules.Rules = rules.Rules[:2]
+	_, err = options("FR", "", "tea")
+	assert.EqualError(t, err, tg.ShippingDefaultError)
+	rules.DefaultError = "We only deliver to Berlin."
+	_, err = options("FR", "", "tea")
+	assert.EqualError(t, err, "We only deliver to Berlin.")
+}
+
+func TestShippingRulesAnswer(t *testing.T) {
+	api, answers := recordAnswers("answerShippingQuery")
+	rules := &tg.ShippingRules{
+		Rules: []*tg.ShippingRule{{Countries: []string{"DE"}, Options: []*tg.ShippingOption{
+			{ID: "post", Title: "Post", Prices: []*tg.LabeledPrice{{Label: "Post", Amount: 500}}},
+		}}},
+		Currency: "USD",
+	}
+	ok, err := rules.Answer(api, &tg.ShippingQuery{ID: "1", ShippingAddress: &tg.ShippingAddress{CountryCode: "DE"}})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	_, err = rules.Answer(api, &tg.ShippingQuery{ID: "2", ShippingAddress: &tg.ShippingAddress{CountryCode: "FR"}})
+	assert.Nil(t, err)
+
+	result := answers()
+	assert.Len(t, result, 2)
+	assert.Equal(t, "1", result[0]["shipping_query_id"])
+	assert.Equal(t, true, result[0]["ok"])
+	assert.Len(t, result[0]["shipping_options"], 1)
+	assert.Nil(t, result[0]["currency"])
+	assert.Equal(t, "2", result[1]["shipping_query_id"])
+	assert.Equal(t, false, result[1]["ok"])
+	assert.Equal(t, tg.ShippingDefaultError, result[1]["error_message"])
+
+	// option totals are checked against the currency before sending
+	rules.Rules[0].Options[0].Prices[0].Amount = 2000000
+	_, err = rules.Answer(api, &tg.ShippingQuery{ID: "3", ShippingAddress: &tg.ShippingAddress{CountryCode: "DE"}})
+	assert.IsType(t, &tg.BuildRequestError{}, err)
+	assert.Len(t, answers(), 2)
+}
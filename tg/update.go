@@ -0,0 +1,229 @@
+package tg
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	MessageKindText                  = "text"
+	MessageKindAnimation             = "animation"
+	MessageKindAudio                 = "audio"
+	MessageKindDocument              = "document"
+	MessageKindGame                  = "game"
+	MessageKindPhoto                 = "photo"
+	MessageKindSticker               = "sticker"
+	MessageKindVideo                 = "video"
+	MessageKindVoice                 = "voice"
+	MessageKindVideoNote             = "video_note"
+	MessageKindContact               = "contact"
+	MessageKindLocation              = "location"
+	MessageKindVenue                 = "venue"
+	MessageKindPoll                  = "poll"
+	MessageKindNewChatMembers        = "new_chat_members"
+	MessageKindLeftChatMember        = "left_chat_member"
+	MessageKindNewChatTitle          = "new_chat_title"
+	MessageKindNewChatPhoto          = "new_chat_photo"
+	MessageKindDeleteChatPhoto       = "delete_chat_photo"
+	MessageKindGroupChatCreated      = "group_chat_created"
+	MessageKindSupergroupChatCreated = "supergroup_chat_created"
+	MessageKindChannelChatCreated    = "channel_chat_created"
+	MessageKindMigrateToChatID       = "migrate_to_chat_id"
+	MessageKindMigrateFromChatID     = "migrate_from_chat_id"
+	MessageKindPinnedMessage         = "pinned_message"
+	MessageKindInvoice               = "invoice"
+	MessageKindSuccessfulPayment     = "successful_payment"
+	MessageKindConnectedWebsite      = "connected_website"
+	MessageKindPassportData          = "passport_data"
+)
+
+const messageEntityBotCommand = "bot_command"
+
+// Type returns the kind of the update, one of AllowedUpdate constants,
+// empty for updates unknown to the library.
+func (u *Update) Type() string {
+	switch {
+	case u.Message != nil:
+		return AllowedUpdateMessage
+	case u.EditedMessage != nil:
+		return AllowedUpdateEditedMessage
+	case u.ChannelPost != nil:
+		return AllowedUpdateChannelPost
+	case u.EditedChannelPost != nil:
+		return AllowedUpdateEditedChannelPost
+	case u.InlineQuery != nil:
+		return AllowedUpdateInlineQuery
+	case u.ChosenInlineResult != nil:
+		return AllowedUpdateChosenInlineResult
+	case u.CallbackQuery != nil:
+		return AllowedUpdateCallbackQuery
+	case u.ShippingQuery != nil:
+		return AllowedUpdateShippingQuery
+	case u.PreCheckoutQuery != nil:
+		return AllowedUpdatePreCheckoutQuery
+	case u.Poll != nil:
+		return AllowedUpdatePoll
+	}
+	return ""
+}
+
+// EffectiveMessage returns the message of the update, for callback queries
+// the message with the button. It is nil for updates without a message.
+func (u *Update) EffectiveMessage() *Message {
+	switch {
+	case u.Message != nil:
+		return u.Message
+	case u.EditedMessage != nil:
+		return u.EditedMessage
+	case u.ChannelPost != nil:
+		return u.ChannelPost
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.Message
+	}
+	return nil
+}
+
+// EffectiveChat returns the chat of the effective message.
+func (u *Update) EffectiveChat() *Chat {
+	if message := u.EffectiveMessage(); message != nil {
+		return message.Chat
+	}
+	return nil
+}
+
+// EffectiveUser returns the user who sent the update, it is nil for channel
+// posts and polls.
+func (u *Update) EffectiveUser() *User {
+	switch {
+	case u.Message != nil:
+		return u.Message.From
+	case u.EditedMessage != nil:
+		return u.EditedMessage.From
+	case u.ChannelPost != nil:
+		return u.ChannelPost.From
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost.From
+	case u.InlineQuery != nil:
+		return u.InlineQuery.From
+	case u.ChosenInlineResult != nil:
+		return u.ChosenInlineResult.From
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.From
+	case u.ShippingQuery != nil:
+		return u.ShippingQuery.From
+	case u.PreCheckoutQuery != nil:
+		return u.PreCheckoutQuery.From
+	}
+	return nil
+}
+
+// Kind returns the content of the message, one of MessageKind constants,
+// empty for messages unknown to the library.
+func (m *Message) Kind() string {
+	switch {
+	case m.Animation != nil: // animations are sent with the document too
+		return MessageKindAnimation
+	case m.Audio != nil:
+		return MessageKindAudio
+	case m.Document != nil:
+		return MessageKindDocument
+	case m.Game != nil:
+		return MessageKindGame
+	case len(m.Photo) > 0:
+		return MessageKindPhoto
+	case m.Sticker != nil:
+		return MessageKindSticker
+	case m.Video != nil:
+		return MessageKindVideo
+	case m.Voice != nil:
+		return MessageKindVoice
+	case m.VideoNote != nil:
+		return MessageKindVideoNote
+	case m.Contact != nil:
+		return MessageKindContact
+	case m.Venue != nil: // venues are sent with the location too
+		return MessageKindVenue
+	case m.Location != nil:
+		return MessageKindLocation
+	case m.Poll != nil:
+		return MessageKindPoll
+	case len(m.NewChatMembers) > 0:
+		return MessageKindNewChatMembers
+	case m.LeftChatMember != nil:
+		return MessageKindLeftChatMember
+	case m.NewChatTitle != "":
+		return MessageKindNewChatTitle
+	case len(m.NewChatPhoto) > 0:
+		return MessageKindNewChatPhoto
+	case m.DeleteChatPhoto:
+		return MessageKindDeleteChatPhoto
+	case m.GroupChatCreated:
+		return MessageKindGroupChatCreated
+	case m.SupergroupChatCreated:
+		return MessageKindSupergroupChatCreated
+	case m.ChannelChatCreated:
+		return MessageKindChannelChatCreated
+	case m.MigrateToChatID != 0:
+		return MessageKindMigrateToChatID
+	case m.MigrateFromChatID != 0:
+		return MessageKindMigrateFromChatID
+	case m.PinnedMessage != nil:
+		return MessageKindPinnedMessage
+	case m.Invoice != nil:
+		return MessageKindInvoice
+	case m.SuccessfulPayment != nil:
+		return MessageKindSuccessfulPayment
+	case m.ConnectedWebsite != "":
+		return MessageKindConnectedWebsite
+	case m.PassportData != nil:
+		return MessageKindPassportData
+	case m.Text != "":
+		return MessageKindText
+	}
+	return ""
+}
+
+// command returns the text of the bot_command entity starting the message and
+// the rest of the text. Offsets of entities are in UTF-16 code units.
+func (m *Message) command() (string, string, bool) {
+	if len(m.Entities) == 0 {
+		return "", "", false
+	}
+	entity := m.Entities[0]
+	if entity.Type != messageEntityBotCommand || entity.Offset != 0 {
+		return "", "", false
+	}
+	text := utf16.Encode([]rune(m.Text))
+	if entity.Length <= 0 || entity.Length > len(text) {
+		return "", "", false
+	}
+	command := string(utf16.Decode(text[:entity.Length]))
+	return command, string(utf16.Decode(text[entity.Length:])), true
+}
+
+// Command returns the command the message starts with, without the slash and
+// the bot username, e.g. "start" for "/start@bot payload". It is empty if the
+// message is not a command.
+func (m *Message) Command() string {
+	command, _, ok := m.command()
+	if !ok {
+		return ""
+	}
+	command = strings.TrimPrefix(command, "/")
+	if i := strings.IndexByte(command, '@'); i >= 0 {
+		command = command[:i]
+	}
+	return command
+}
+
+// CommandArgs returns the text after the command, e.g. "payload" for
+// "/start@bot payload". It is empty if the message is not a command.
+func (m *Message) CommandArgs() string {
+	_, args, ok := m.command()
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(args)
+}
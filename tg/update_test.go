@@ -0,0 +1,74 @@
+package tg_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/websuslik/unibot/tg"
+	"testing"
+)
+
+func TestUpdateAccessors(t *testing.T) {
+	user := &tg.User{ID: 1}
+	chat := &tg.Chat{ID: 2}
+	message := &tg.Message{MessageID: 3, From: user, Chat: chat}
+
+	update := &tg.Update{EditedMessage: message}
+	assert.Equal(t, tg.AllowedUpdateEditedMessage, update.Type())
+	assert.Equal(t, message, update.EffectiveMessage())
+	assert.Equal(t, chat, update.EffectiveChat())
+	assert.Equal(t, user, update.EffectiveUser())
+
+	query := &tg.CallbackQuery{From: &tg.User{ID: 4}, Message: message}
+	update = &tg.Update{CallbackQuery: query}
+	assert.Equal(t, tg.AllowedUpdateCallbackQuery, update.Type())
+	assert.Equal(t, message, update.EffectiveMessage())
+	assert.Equal(t, chat, update.EffectiveChat())
+	assert.Equal(t, query.From, update.EffectiveUser())
+
+	update = &tg.Update{InlineQuery: &tg.InlineQuery{From: user}}
+	assert.Equal(t, tg.AllowedUpdateInlineQuery, update.Type())
+	assert.Nil(t, update.EffectiveMessage())
+	assert.Nil(t, update.EffectiveChat())
+	assert.Equal(t, user, update.EffectiveUser())
+
+	update = &tg.Update{Poll: &tg.Poll{ID: "poll"}}
+	assert.Equal(t, tg.AllowedUpdatePoll, update.Type())
+	assert.Nil(t, update.EffectiveUser())
+
+	assert.Equal(t, "", (&tg.Update{UpdateID: 5}).Type())
+}
+
+func TestMessageKind(t *testing.T) {
+	assert.Equal(t, tg.MessageKindText, (&tg.Message{Text: "hi"}).Kind())
+	assert.Equal(t, tg.MessageKindPhoto, (&tg.Message{Photo: []*tg.PhotoSize{{}}, Caption: "hi"}).Kind())
+	assert.Equal(t, tg.MessageKindAnimation, (&tg.Message{Animation: &tg.Animation{}, Document: &tg.Document{}}).Kind())
+	assert.Equal(t, tg.MessageKindVenue, (&tg.Message{Venue: &tg.Venue{}, Location: &tg.Location{}}).Kind())
+	assert.Equal(t, tg.MessageKindMigrateToChatID, (&tg.Message{MigrateToChatID: 1}).Kind())
+	assert.Equal(t, "", (&tg.Message{}).Kind())
+}
+
+func TestMessageCommand(t *testing.T) {
+	message := &tg.Message{
+		Text:     "/start@bot  payload ",
+		Entities: []*tg.MessageEntity{{Type: "bot_command", Offset: 0, Length: 10}},
+	}
+	assert.Equal(t, "start", message.Command())
+	assert.Equal(t, "payload", message.CommandArgs())
+
+	// offsets are in UTF-16 code units, the emoji takes two of them
+	message = &tg.Message{
+		Text:     "/say 😀 hi",
+		Entities: []*tg.MessageEntity{{Type: "bot_command", Offset: 0, Length: 4}},
+	}
+	assert.Equal(t, "say", message.Command())
+	assert.Equal(t, "😀 hi", message.CommandArgs())
+
+	message = &tg.Message{
+		Text:     "😀 /start",
+		Entities: []*tg.MessageEntity{{Type: "bot_command", Offset: 3, Length: 6}},
+	}
+	assert.Equal(t, "", message.Command())
+	assert.Equal(t, "", message.CommandArgs())
+
+	message = &tg.Message{Text: "/start"}
+	assert.Equal(t, "", message.Command())
+}
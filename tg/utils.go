@@ -3,6 +3,7 @@ package tg
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"mime/multipart"
 	"os"
@@ -12,8 +13,8 @@ import (
 	"strings"
 )
 
-func buildJSONRequestArgs(params MethodArgs) (*RequestArgs, error) {
-	body, err := json.Marshal(params)
+func buildJSONRequestArgs(codec Codec, params interface{}) (*RequestArgs, error) {
+	body, err := codec.Marshal(params)
 	if err != nil {
 		return nil, err
 	}
@@ -56,6 +57,34 @@ func buildMultipartRequestArgs(args map[string]string, uploadFiles []*InputFile)
 	}, nil
 }
 
+func marshallParams(codec Codec, params interface{}) (map[string]string, error) {
+	switch v := params.(type) {
+	case nil:
+		return map[string]string{}, nil
+	case map[string]string:
+		return v, nil
+	case map[string]interface{}:
+		result := make(map[string]string)
+		for key, value := range v {
+			if s, ok := value.(string); ok {
+				result[key] = s
+				continue
+			}
+			val, err := codec.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = string(val)
+		}
+		return result, nil
+	}
+	value := reflect.ValueOf(params)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported params type %T", params)
+	}
+	return marshallToMap(codec, params), nil
+}
+
 func getTagKey(tag string) string {
 	if idx := strings.Index(tag, ","); idx != -1 {
 		return tag[:idx]
@@ -63,10 +92,18 @@ func getTagKey(tag string) string {
 	return tag
 }
 
-func marshallToMap(args interface{}) map[string]string {
+func marshallToMap(codec Codec, args interface{}) map[string]string {
 	result := make(map[string]string)
 	t := reflect.ValueOf(args).Elem()
 	for i := 0; i < t.NumField(); i++ {
+		if t.Type().Field(i).Anonymous {
+			if field := t.Field(i); field.Kind() == reflect.Ptr && !field.IsNil() {
+				for key, val := range marshallToMap(codec, field.Interface()) {
+					result[key] = val
+				}
+			}
+			continue
+		}
 		value := t.Field(i).Interface()
 		tag := t.Type().Field(i).Tag.Get("json")
 		if tag == "-" {
@@ -92,12 +129,12 @@ func marshallToMap(args interface{}) map[string]string {
 			result[key] = strconv.FormatBool(v)
 		case []interface{}:
 			if len(v) > 0 {
-				val, _ := json.Marshal(v)
+				val, _ := codec.Marshal(v)
 				result[key] = string(val)
 			}
 		default:
 			if v != nil {
-				val, _ := json.Marshal(v)
+				val, _ := codec.Marshal(v)
 				result[key] = string(val)
 			}
 		}
@@ -105,16 +142,162 @@ func marshallToMap(args interface{}) map[string]string {
 	return result
 }
 
-func buildOptionalMessage(response *json.RawMessage) (*OptionalMessage, error) {
-	var success *bool
-	var message *Message
-	optMessage := OptionalMessage{Successful: true}
-	if err := json.Unmarshal(*response, &success); err != nil {
-		if err = json.Unmarshal(*response, &message); err != nil {
-			return nil, err
+// callEdit executes an edit method. Its result is True for inline messages.
+func callEdit(api *API, method string, args MethodArgs) (*Message, error) {
+	result, err := Call[json.RawMessage](api, method, args)
+	if err != nil || bytes.Equal(result, []byte("true")) {
+		return nil, err
+	}
+	message := &Message{}
+	if err = api.codec().Unmarshal(result, message); err != nil {
+		return nil, NewParseResponseBodyError(err.Error())
+	}
+	return message, nil
+}
+
+// callRaw executes the method with params marshalled by methodParams.
+func callRaw(api *API, method string, params json.RawMessage, files []*InputFile, result interface{}) error {
+	if len(files) == 0 {
+		return api.Call(method, params, result)
+	}
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return NewBuildRequestError(err.Error())
+	}
+	return api.CallMultipart(method, fields, files, result)
+}
+
+// methodName returns the method of args generated for it, e.g. sendPhoto for *SendPhotoArgs.
+func methodName(args MethodArgs) (string, error) {
+	t := reflect.TypeOf(args)
+	if t.Kind() != reflect.Ptr || !strings.HasSuffix(t.Elem().Name(), "Args") || t.Elem().Name() == "Args" {
+		return "", fmt.Errorf("unsupported args type %T", args)
+	}
+	name := strings.TrimSuffix(t.Elem().Name(), "Args")
+	return strings.ToLower(name[:1]) + name[1:], nil
+}
+
+// uploadFiles returns the files of args which should be uploaded.
+func uploadFiles(args MethodArgs) []*InputFile {
+	var files []*InputFile
+	addMedia := func(value interface{}) {
+		if media, ok := value.(InputMedia); ok && media != nil {
+			for _, file := range media.getMedia() {
+				if file.isAllSet() {
+					files = append(files, file)
+				}
+			}
+		}
+	}
+	v := reflect.ValueOf(args).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		switch value := v.Field(i).Interface().(type) {
+		case *InputFile:
+			if value.isAllSet() {
+				files = append(files, value)
+			}
+		case *InputMedia:
+			if value != nil {
+				addMedia(*value)
+			}
+		case []InputMedia:
+			for _, media := range value {
+				addMedia(media)
+			}
+		}
+	}
+	return files
+}
+
+// methodParams marshals args of a generated method so it can be stored and executed later.
+func methodParams(args MethodArgs) (string, json.RawMessage, []*InputFile, error) {
+	method, err := methodName(args)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	params, err := json.Marshal(args)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return method, params, uploadFiles(args), nil
+}
+
+// argsChatID returns the chat of the method args, nil if there is no chat.
+func argsChatID(args MethodArgs) *ChatID {
+	v := reflect.ValueOf(args)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	field, ok := v.Elem().Type().FieldByName("ChatID")
+	if !ok {
+		return nil
+	}
+	// ChatID of MessageRef is promoted through a pointer which can be nil
+	value, err := v.Elem().FieldByIndexErr(field.Index)
+	if err != nil {
+		return nil
+	}
+	chatID, _ := value.Interface().(*ChatID)
+	return chatID
+}
+
+// withChatID returns a copy of the method args with the chat replaced.
+func withChatID(args MethodArgs, chatID *ChatID) (MethodArgs, bool) {
+	if argsChatID(args) == nil {
+		return nil, false
+	}
+	v := reflect.ValueOf(args).Elem()
+	field, _ := v.Type().FieldByName("ChatID")
+	result := reflect.New(v.Type())
+	result.Elem().Set(v)
+	target := result.Elem()
+	for _, index := range field.Index[:len(field.Index)-1] {
+		// embedded structs are copied too, so args are not changed
+		embedded := target.Field(index)
+		copied := reflect.New(embedded.Type().Elem())
+		copied.Elem().Set(embedded.Elem())
+		embedded.Set(copied)
+		target = copied.Elem()
+	}
+	target.Field(field.Index[len(field.Index)-1]).Set(reflect.ValueOf(chatID))
+	return result.Interface().(MethodArgs), true
+}
+
+// callRejected reports whether the call is rejected for good. Flood waits,
+// migrations, server errors and network errors are worth a retry.
+func callRejected(err error) bool {
+	switch e := err.(type) {
+	case *BuildRequestError:
+		return true
+	case *APIError:
+		if e.Parameters != nil && (e.Parameters.RetryAfter > 0 || e.Parameters.MigrateToChatID != 0) {
+			return false
 		}
-		optMessage.Message = message
-		return &optMessage, nil
+		return e.Code < 500
+	}
+	return false
+}
+
+// migratedParams returns the params of a call failed because of a migrated
+// group with the chat_id of the new chat.
+func migratedParams(params json.RawMessage, err error) (json.RawMessage, bool) {
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Parameters == nil || apiErr.Parameters.MigrateToChatID == 0 {
+		return nil, false
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(params, &fields) != nil {
+		return nil, false
+	}
+	if _, ok = fields["chat_id"]; !ok {
+		return nil, false
+	}
+	fields["chat_id"], _ = json.Marshal(apiErr.Parameters.MigrateToChatID)
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false
 	}
-	return &optMessage, nil
+	return data, true
 }
@@ -0,0 +1,50 @@
+package tg
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+)
+
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookHandler receives updates sent by Telegram to the webhook.
+// Errors of the Handler are reported to OnError and the update is acknowledged,
+// otherwise Telegram would send it again.
+type WebhookHandler struct {
+	Handler     UpdateHandler
+	SecretToken string               // Optional, the request header must match it
+	OnError     func(err error)      // Optional
+	OnUpdate    func(update *Update) // Optional, called before the Handler
+}
+
+func (h *WebhookHandler) checkSecretToken(r *http.Request) bool {
+	if h.SecretToken == "" {
+		return true
+	}
+	token := r.Header.Get(SecretTokenHeader)
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.SecretToken)) == 1
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.checkSecretToken(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	update := &Update{}
+	if err := json.NewDecoder(r.Body).Decode(update); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if h.OnUpdate != nil {
+		h.OnUpdate(update)
+	}
+	if _, err := h.Handler.HandleUpdate(update); err != nil && h.OnError != nil {
+		h.OnError(err)
+	}
+	w.WriteHeader(http.StatusOK)
+}